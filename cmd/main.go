@@ -64,7 +64,7 @@ func main() {
 	}
 
 	// setup configuration
-	cfg := config.NewConfig()
+	cfg := config.NewConfig(webhooks.CompileRule)
 	// initial configuration parsing
 	if err := configuration.EnsureFirstConfigurationLoad(
 		flags.configMap, mgr.GetAPIReader(), cfg,
@@ -73,6 +73,22 @@ func main() {
 		os.Exit(1)
 	}
 
+	// the indexes for the unique rules have to be there before the cache is started
+	if err := webhooks.SetupUniqueIndexes(mgr.GetFieldIndexer(), cfg); err != nil {
+		entryLog.Error(err, "unable to set up indexes")
+		os.Exit(1)
+	}
+
+	// the webhooks are registered for the operations used in the configuration, the
+	// configuration reconciler registers them again when they change
+	ensureWebhookConfigurations := func(operations []string) error {
+		return utilswebhook.EnsureWebhookConfigurations(
+			flags.serviceName, flags.webhookCertificate,
+			flags.validatingWebhookConfiguration, "",
+			flags.enableValidatingWebhook, false, operations,
+			mgr.GetAPIReader(), mgr.GetClient())
+	}
+
 	// setup reconcilers to keep configuration up-to-date
 	builder.
 		ControllerManagedBy(mgr).
@@ -81,6 +97,16 @@ func main() {
 			configuration.GetConfigurationNamespacedName())).
 		Complete(reconcilers.NewConfigurationReconciler(
 			log.WithName("configurationReconciler"),
+			cfg, ensureWebhookConfigurations))
+
+	// and the ConfigMaps referenced by the rules
+	builder.
+		ControllerManagedBy(mgr).
+		Named("configmapref").
+		For(&corev1.ConfigMap{}).
+		WithEventFilter(predicates.GetReferencedConfigMapPredicates(cfg.IsConfigMapReferenced)).
+		Complete(reconcilers.NewConfigMapRefReconciler(
+			log.WithName("configMapRefReconciler"),
 			cfg))
 
 	// setup all TLS and webhook configuration related stuff
@@ -127,11 +153,7 @@ func main() {
 	hookServer := mgr.GetWebhookServer()
 
 	// ensure validating/mutating webhook configuration for the webhook server is in place
-	if err := utilswebhook.EnsureWebhookConfigurations(
-		flags.serviceName, flags.webhookCertificate,
-		flags.validatingWebhookConfiguration, "",
-		flags.enableValidatingWebhook, false,
-		mgr.GetAPIReader(), mgr.GetClient()); err != nil {
+	if err := ensureWebhookConfigurations(cfg.GetOperations()); err != nil {
 		entryLog.Error(err, "unable to ensure webhook configurations")
 		os.Exit(1)
 	}
@@ -141,7 +163,7 @@ func main() {
 		entryLog.Info("setting up genericValidator")
 		// hookServer.Register(utilswebhook.ValidatingPath, &webhook.Admission{Handler: &namespaceValidator{Client: mgr.GetClient()}})
 		hookServer.Register(utilswebhook.ValidatingPath, &webhook.Admission{
-			Handler: webhooks.NewGenericValidator(mgr.GetClient(), log.WithName("genericValidator"), cfg),
+			Handler: webhooks.NewGenericValidator(mgr.GetClient(), mgr.GetCache(), log.WithName("genericValidator"), cfg),
 		})
 	}
 
@@ -3,10 +3,13 @@ module github.com/safanaj/k8s-generic-validator
 go 1.14
 
 require (
+	github.com/Masterminds/semver/v3 v3.1.1
 	github.com/go-logr/logr v0.1.0
+	github.com/google/cel-go v0.12.6
 	github.com/jetstack/cert-manager v0.16.1
 	github.com/spf13/pflag v1.0.5
-	gopkg.in/yaml.v3 v3.0.0-20190905181640-827449938966
+	github.com/xeipuuv/gojsonschema v1.2.0
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 	k8s.io/api v0.18.6
 	k8s.io/apimachinery v0.18.6
 	k8s.io/client-go v0.18.6
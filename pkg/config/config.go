@@ -3,69 +3,553 @@ package config
 import (
 	"fmt"
 	"gopkg.in/yaml.v3"
+	"path"
+	"strings"
 	"sync"
+
+	ar "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
+// Any is the wildcard usable as group and/or version in ForKindRules.ApiVersion
+const Any string = "*"
+
+// operations used when none is configured, the only ones registered before they were configurable
+var defaultOperations = []string{string(ar.Create), string(ar.Update)}
+
+var allOperations = sets.NewString(
+	string(ar.Create), string(ar.Update), string(ar.Delete), string(ar.Connect))
+
 type Rule struct {
-	Field string      `yaml:"field"`
-	Type  string      `yaml:"type"`
-	Op    string      `yaml:"op"`
+	// Field is a field path, see the fieldpath package for the syntax, with the old. prefix
+	// it is into the old object (on UPDATE and DELETE), the rule does not apply when there is none (on CREATE)
+	Field string `yaml:"field"`
+	Type  string `yaml:"type"`
+	Op    string `yaml:"op"`
+	// Value strings can be templates resolved at admission time, with expressions about the
+	// object, oldObject, request and namespace (i.e. {{ namespace.labels.team }}), the rule
+	// does not apply when a template uses the oldObject and there is none (on CREATE)
 	Value interface{} `yaml:"value"`
+	// ValueFrom takes the value from another field of the object, instead of Value
+	ValueFrom *ValueFrom `yaml:"valueFrom,omitempty"`
+	// Operations restricts the rule to some of the operations of its ForKindRules
+	Operations []string `yaml:"operations,omitempty"`
+	// Component is the part of the reference (registry, repository, tag or digest)
+	// a rule of type image is about, the whole normalized reference if empty
+	Component string `yaml:"component,omitempty"`
+	// IgnoreCase makes the string comparisons case insensitive
+	IgnoreCase bool `yaml:"ignoreCase,omitempty"`
+	// Quantifier (all, any or none) tells how many of the values a field path with wildcards
+	// or filters resolves to have to verify the rule, default is all
+	Quantifier string `yaml:"quantifier,omitempty"`
+	// OnMissing (deny, allow or default) tells what to do when the field is missing, default is deny
+	OnMissing string `yaml:"onMissing,omitempty"`
+	// OnInvalid (deny, allow or default) tells what to do when the field value is not valid
+	// for the type (i.e. not a semver), default is the OnMissing one
+	OnInvalid string `yaml:"onInvalid,omitempty"`
+	// Default is the value of the missing (or invalid) field with OnMissing (or OnInvalid) default
+	Default interface{} `yaml:"default,omitempty"`
+
+	// a rule can be a group of rules instead of a field/op/value one
+	AllOf []Rule `yaml:"allOf,omitempty"`
+	AnyOf []Rule `yaml:"anyOf,omitempty"`
+	Not   *Rule  `yaml:"not,omitempty"`
+	// When makes the rule conditional, it is verified only if When is
+	When *Rule `yaml:"when,omitempty"`
+
+	// Expression is the CEL expression of a rule of type cel, evaluated against
+	// object, oldObject, request and namespaceObject
+	Expression string `yaml:"expression,omitempty"`
+
+	// Scope (cluster or namespace) is where a rule of type unique or countLimit looks for the
+	// other objects, default is cluster for unique and namespace for countLimit
+	Scope string `yaml:"scope,omitempty"`
+
+	// CountLimit limits the number of objects of the kind for a rule of type countLimit
+	CountLimit *CountLimit `yaml:"countLimit,omitempty"`
+
+	// Schema is the JSON Schema (as yaml) of a rule of type jsonSchema, or SchemaRef is the name
+	// of one in the configuration schemas
+	Schema    interface{} `yaml:"schema,omitempty"`
+	SchemaRef string      `yaml:"schemaRef,omitempty"`
+
+	// Lookup is the object a rule of type lookup gets from the cluster
+	Lookup *Lookup `yaml:"lookup,omitempty"`
+
+	// Compiled is set by the RuleCompiler
+	Compiled interface{} `yaml:"-"`
+}
+
+// ValueFrom references where the value of a rule is taken from, one of Field or ConfigMapRef
+type ValueFrom struct {
+	// Field is a field path into the same object, it has to address a single value, with the
+	// old. prefix it is into the old object and the rule does not apply when there is none (on CREATE)
+	Field string `yaml:"field,omitempty"`
+	// ConfigMapRef is a list of values (i.e. for In and NotIn) in a ConfigMap
+	ConfigMapRef *ConfigMapRef `yaml:"configMapRef,omitempty"`
+}
+
+// Lookup references an object in the cluster, ApiVersion, Kind, Name and Namespace are
+// templates that can use fields of the object in the request (i.e. {{ object.spec.serviceName }}
+// or {{ object.spec.scaleTargetRef.kind }})
+type Lookup struct {
+	ApiVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Name       string `yaml:"name"`
+	// Namespace is the one of the request if empty, it is not used for cluster scoped kinds
+	Namespace     string `yaml:"namespace,omitempty"`
+	ClusterScoped bool   `yaml:"clusterScoped,omitempty"`
+	// Rules are verified against the referenced object, without rules it just has to exist
+	Rules []Rule `yaml:"rules,omitempty"`
+	// OnNotFound (deny or allow) tells what to do when the object does not exist, default is deny
+	OnNotFound string `yaml:"onNotFound,omitempty"`
+	// OnUnavailable (deny or allow) tells what to do when the object can't be got in Timeout
+	// (i.e. the cache is not synced yet), default is deny
+	OnUnavailable string `yaml:"onUnavailable,omitempty"`
+	// Timeout is a duration (i.e. 500ms), default is 2s
+	Timeout string `yaml:"timeout,omitempty"`
+}
+
+// CountLimit is the maximum number of objects of a kind (in the namespace or in the cluster),
+// the one in the request included
+type CountLimit struct {
+	Limit int `yaml:"limit"`
+	// LabelSelector and Rules select the objects that are counted
+	LabelSelector *LabelSelector `yaml:"labelSelector,omitempty"`
+	Rules         []Rule         `yaml:"rules,omitempty"`
+	// Annotation is the annotation of the namespace overriding Limit
+	Annotation string `yaml:"annotation,omitempty"`
+}
+
+func (r Rule) String() string {
+	var desc string
+	switch {
+	case len(r.AllOf) > 0:
+		desc = fmt.Sprintf("allOf%v", r.AllOf)
+	case len(r.AnyOf) > 0:
+		desc = fmt.Sprintf("anyOf%v", r.AnyOf)
+	case r.Not != nil:
+		desc = fmt.Sprintf("not[%v]", *r.Not)
+	case r.Lookup != nil:
+		name := r.Lookup.Name
+		if !r.Lookup.ClusterScoped && r.Lookup.Namespace != "" {
+			name = r.Lookup.Namespace + "/" + name
+		}
+		desc = fmt.Sprintf("lookup[%s %s %s]", r.Lookup.ApiVersion, r.Lookup.Kind, name)
+		if len(r.Lookup.Rules) > 0 {
+			desc = fmt.Sprintf("%s%v", desc, r.Lookup.Rules)
+		}
+	case r.CountLimit != nil:
+		desc = fmt.Sprintf("countLimit[%d]", r.CountLimit.Limit)
+		if len(r.CountLimit.Rules) > 0 {
+			desc = fmt.Sprintf("%s%v", desc, r.CountLimit.Rules)
+		}
+	case r.SchemaRef != "":
+		desc = strings.TrimSpace(fmt.Sprintf("%s[%s] %s", r.Type, r.SchemaRef, r.Field))
+	case r.Expression != "":
+		desc = fmt.Sprintf("%s[%s]", r.Type, r.Expression)
+	case r.ValueFrom != nil && r.ValueFrom.ConfigMapRef != nil:
+		desc = fmt.Sprintf("%s %s valueFrom(configMap %s) (%s)", r.Field, r.Op, r.ValueFrom.ConfigMapRef, r.Type)
+	case r.ValueFrom != nil:
+		desc = fmt.Sprintf("%s %s valueFrom(%s) (%s)", r.Field, r.Op, r.ValueFrom.Field, r.Type)
+	case r.Op == "" && r.Value == nil:
+		desc = strings.TrimSpace(fmt.Sprintf("%s %s %s", r.Type, r.Field, r.Scope))
+	case r.Value == nil && r.Component != "":
+		desc = fmt.Sprintf("%s %s %s", r.Field, r.Component, r.Op)
+	case r.Value == nil:
+		desc = fmt.Sprintf("%s %s", r.Field, r.Op)
+	case r.Component != "":
+		desc = fmt.Sprintf("%s %s %s %v (%s)", r.Field, r.Component, r.Op, r.Value, r.Type)
+	case r.Type == "":
+		desc = fmt.Sprintf("%s %s %v", r.Field, r.Op, r.Value)
+	default:
+		desc = fmt.Sprintf("%s %s %v (%s)", r.Field, r.Op, r.Value, r.Type)
+	}
+	if r.When != nil {
+		desc = fmt.Sprintf("when[%v] %s", *r.When, desc)
+	}
+	return desc
+}
+
+// RuleCompiler is called by ParseYaml on every rule (nested ones included), to validate it and to prepare once
+// what is needed to verify it (i.e. regular expressions), so that errors surface loading
+// the configuration and not at admission time
+type RuleCompiler func(rule *Rule) error
+
+// LabelSelector is the yaml counterpart of metav1.LabelSelector
+type LabelSelector struct {
+	MatchLabels      map[string]string          `yaml:"matchLabels,omitempty"`
+	MatchExpressions []LabelSelectorRequirement `yaml:"matchExpressions,omitempty"`
+}
+
+type LabelSelectorRequirement struct {
+	Key      string   `yaml:"key"`
+	Operator string   `yaml:"operator"`
+	Values   []string `yaml:"values,omitempty"`
+}
+
+// ToSelector converts the selector to a labels.Selector
+func (ls *LabelSelector) ToSelector() (labels.Selector, error) {
+	selector := &metav1.LabelSelector{MatchLabels: ls.MatchLabels}
+	for _, req := range ls.MatchExpressions {
+		selector.MatchExpressions = append(selector.MatchExpressions, metav1.LabelSelectorRequirement{
+			Key:      req.Key,
+			Operator: metav1.LabelSelectorOperator(req.Operator),
+			Values:   req.Values,
+		})
+	}
+	return metav1.LabelSelectorAsSelector(selector)
+}
+
+// MatchBlock selects objects, all the criteria that are set have to match.
+// Namespaces and Names are glob patterns, cluster scoped objects don't match
+// Namespaces nor NamespaceSelector
+type MatchBlock struct {
+	Namespaces        []string       `yaml:"namespaces,omitempty"`
+	Names             []string       `yaml:"names,omitempty"`
+	LabelSelector     *LabelSelector `yaml:"labelSelector,omitempty"`
+	NamespaceSelector *LabelSelector `yaml:"namespaceSelector,omitempty"`
+
+	labelSelector     labels.Selector
+	namespaceSelector labels.Selector
+}
+
+func (m *MatchBlock) compile() error {
+	for _, pattern := range append(append([]string{}, m.Namespaces...), m.Names...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+	}
+	var err error
+	if m.LabelSelector != nil {
+		if m.labelSelector, err = m.LabelSelector.ToSelector(); err != nil {
+			return fmt.Errorf("invalid labelSelector: %v", err)
+		}
+	}
+	if m.NamespaceSelector != nil {
+		if m.namespaceSelector, err = m.NamespaceSelector.ToSelector(); err != nil {
+			return fmt.Errorf("invalid namespaceSelector: %v", err)
+		}
+	}
+	return nil
+}
+
+func matchesAnyPattern(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches checks the object against the block, namespaceLabels is called only when
+// a NamespaceSelector is set
+func (m *MatchBlock) Matches(namespace, name string, objLabels map[string]string,
+	namespaceLabels func() (map[string]string, error)) (bool, error) {
+	if len(m.Namespaces) > 0 && (namespace == "" || !matchesAnyPattern(m.Namespaces, namespace)) {
+		return false, nil
+	}
+	if len(m.Names) > 0 && !matchesAnyPattern(m.Names, name) {
+		return false, nil
+	}
+	if m.labelSelector != nil && !m.labelSelector.Matches(labels.Set(objLabels)) {
+		return false, nil
+	}
+	if m.namespaceSelector != nil {
+		if namespace == "" {
+			return false, nil
+		}
+		nsLabels, err := namespaceLabels()
+		if err != nil {
+			return false, err
+		}
+		if !m.namespaceSelector.Matches(labels.Set(nsLabels)) {
+			return false, nil
+		}
+	}
+	return true, nil
 }
 
 type ForKindRules struct {
+	// ApiVersion can be group/version (apps/v1), just the version for the core group (v1),
+	// a group with any version (apps/*) or empty to match any group and version
 	ApiVersion string `yaml:"apiVersion,omitempty"`
-	Kind       string `yaml:"kind"`
-	Rules      []Rule `yaml:"rules"`
+	Kind       string `yaml:"kind,omitempty"`
+	// Resource is the plural name of the resource (services), when set it has to match as well
+	Resource string `yaml:"resource,omitempty"`
+	// Operations the rules apply to (CREATE, UPDATE, DELETE, CONNECT or *), default is CREATE and UPDATE
+	Operations []string `yaml:"operations,omitempty"`
+	// Match and Exclude select the objects the rules apply to
+	Match   *MatchBlock `yaml:"match,omitempty"`
+	Exclude *MatchBlock `yaml:"exclude,omitempty"`
+	Rules   []Rule      `yaml:"rules"`
+}
+
+// Applies checks the object against Match and Exclude
+func (k *ForKindRules) Applies(namespace, name string, objLabels map[string]string,
+	namespaceLabels func() (map[string]string, error)) (bool, error) {
+	if k.Match != nil {
+		if ok, err := k.Match.Matches(namespace, name, objLabels, namespaceLabels); !ok || err != nil {
+			return false, err
+		}
+	}
+	if k.Exclude != nil {
+		excluded, err := k.Exclude.Matches(namespace, name, objLabels, namespaceLabels)
+		return !excluded, err
+	}
+	return true, nil
 }
 
 type Config struct {
 	sync.RWMutex
-	cache         map[string][]Rule // this map is using the Kind (and api version? (gvk)?) as key
-	ForKindsRules []ForKindRules    `yaml:"forKindsRules,omitempty"`
-	AdminGroups   []string          `yaml:"adminGroups,omitempty"`
+	compiler      RuleCompiler
+	cache         map[schema.GroupVersionKind][]int // indexes of ForKindsRules, group and version can be Any
+	ForKindsRules []ForKindRules                    `yaml:"forKindsRules,omitempty"`
+	AdminGroups   []string                          `yaml:"adminGroups,omitempty"`
+	// Schemas are JSON Schemas that rules of type jsonSchema can reference by name
+	Schemas map[string]interface{} `yaml:"schemas,omitempty"`
+
+	// configMapRefs are the ConfigMaps referenced by the rules, their values lists are kept
+	// up to date by a reconciler
+	configMapRefs   map[types.NamespacedName]bool
+	configMapValues map[types.NamespacedName]map[string][]interface{}
 }
 
-func NewConfig() *Config { return &Config{} }
+// NewConfig returns an empty configuration, compiler can be nil
+func NewConfig(compiler RuleCompiler) *Config { return &Config{compiler: compiler} }
 
-func (cfg *Config) ParseYaml(data []byte) error {
-	cfg.Lock()
-	defer cfg.Unlock()
+func parseApiVersion(apiVersion string) (schema.GroupVersion, error) {
+	if apiVersion == "" || apiVersion == Any {
+		return schema.GroupVersion{Group: Any, Version: Any}, nil
+	}
+	return schema.ParseGroupVersion(apiVersion)
+}
+
+// normalizeOperations validates the operations and returns them upper case, with * expanded
+func normalizeOperations(operations []string) ([]string, error) {
+	normalized := sets.NewString()
+	for _, op := range operations {
+		op = strings.ToUpper(op)
+		if op == string(ar.OperationAll) {
+			normalized.Insert(allOperations.List()...)
+			continue
+		}
+		if !allOperations.Has(op) {
+			return nil, fmt.Errorf("unknown operation: %s", op)
+		}
+		normalized.Insert(op)
+	}
+	return normalized.List(), nil
+}
+
+func hasOperation(operations []string, operation string) bool {
+	for _, op := range operations {
+		if op == operation {
+			return true
+		}
+	}
+	return false
+}
 
-	err := yaml.Unmarshal(data, cfg)
+// ParseYaml replaces the configuration, that is left untouched on errors
+func (cfg *Config) ParseYaml(data []byte) error {
+	parsed := &Config{compiler: cfg.compiler}
+	err := yaml.Unmarshal(data, parsed)
 	if err != nil {
 		return fmt.Errorf("Error parsing yaml: %v", err)
 	}
+	if err := parsed.build(); err != nil {
+		return err
+	}
+
+	cfg.Lock()
+	defer cfg.Unlock()
+	cfg.cache = parsed.cache
+	cfg.ForKindsRules = parsed.ForKindsRules
+	cfg.AdminGroups = parsed.AdminGroups
+	cfg.configMapRefs = parsed.configMapRefs
+	cfg.Schemas = parsed.Schemas
+	return nil
+}
 
+// build sets the defaults, validates and compiles the rules and builds the cache
+func (cfg *Config) build() error {
 	// set default of AdminGroups is not defined
 	if len(cfg.AdminGroups) == 0 {
 		cfg.AdminGroups = []string{"system:masters"}
 	}
 
-	// build cache
-	cfg.cache = make(map[string][]Rule)
-	for _, k := range cfg.ForKindsRules {
-		key := k.Kind
-		if _, found := cfg.cache[key]; found {
-			cfg.cache[key] = append(cfg.cache[key], k.Rules...)
-		} else {
-			cfg.cache[key] = append([]Rule{}, k.Rules...)
+	// build cache, rules just for a resource are using an empty Kind as key
+	cfg.cache = make(map[schema.GroupVersionKind][]int)
+	for i := range cfg.ForKindsRules {
+		k := &cfg.ForKindsRules[i]
+		if k.Kind == "" && k.Resource == "" {
+			return fmt.Errorf("forKindsRules[%d] needs at least one of kind or resource", i)
+		}
+		gv, err := parseApiVersion(k.ApiVersion)
+		if err != nil {
+			return fmt.Errorf("forKindsRules[%d] has an invalid apiVersion: %v", i, err)
+		}
+		if len(k.Operations) == 0 {
+			k.Operations = defaultOperations
+		}
+		if k.Operations, err = normalizeOperations(k.Operations); err != nil {
+			return fmt.Errorf("forKindsRules[%d]: %v", i, err)
+		}
+		if k.Match != nil {
+			if err := k.Match.compile(); err != nil {
+				return fmt.Errorf("forKindsRules[%d].match: %v", i, err)
+			}
+		}
+		if k.Exclude != nil {
+			if err := k.Exclude.compile(); err != nil {
+				return fmt.Errorf("forKindsRules[%d].exclude: %v", i, err)
+			}
+		}
+		for j := range k.Rules {
+			rule := &k.Rules[j]
+			if rule.Operations, err = normalizeOperations(rule.Operations); err != nil {
+				return fmt.Errorf("forKindsRules[%d].rules[%d]: %v", i, j, err)
+			}
+			for _, op := range rule.Operations {
+				if !hasOperation(k.Operations, op) {
+					return fmt.Errorf("forKindsRules[%d].rules[%d]: operation %s is not one of %v",
+						i, j, op, k.Operations)
+				}
+			}
+			if err := cfg.compileRule(rule, fmt.Sprintf("forKindsRules[%d].rules[%d]", i, j)); err != nil {
+				return err
+			}
+		}
+		key := gv.WithKind(k.Kind)
+		cfg.cache[key] = append(cfg.cache[key], i)
+	}
+	return nil
+}
+
+// compileRule calls the compiler on the nested rules and then on the rule
+func (cfg *Config) compileRule(rule *Rule, where string) error {
+	if rule.ValueFrom != nil && rule.ValueFrom.ConfigMapRef != nil {
+		cfg.addConfigMapRef(rule.ValueFrom.ConfigMapRef)
+	}
+	if rule.SchemaRef != "" {
+		if rule.Schema != nil {
+			return fmt.Errorf("%s: a rule can't have both schema and schemaRef", where)
+		}
+		refSchema, found := cfg.Schemas[rule.SchemaRef]
+		if !found {
+			return fmt.Errorf("%s: schema %s not found", where, rule.SchemaRef)
+		}
+		rule.Schema = refSchema
+	}
+	for i := range rule.AllOf {
+		if err := cfg.compileRule(&rule.AllOf[i], fmt.Sprintf("%s.allOf[%d]", where, i)); err != nil {
+			return err
+		}
+	}
+	for i := range rule.AnyOf {
+		if err := cfg.compileRule(&rule.AnyOf[i], fmt.Sprintf("%s.anyOf[%d]", where, i)); err != nil {
+			return err
+		}
+	}
+	if rule.Not != nil {
+		if err := cfg.compileRule(rule.Not, where+".not"); err != nil {
+			return err
+		}
+	}
+	if rule.Lookup != nil {
+		for i := range rule.Lookup.Rules {
+			if err := cfg.compileRule(&rule.Lookup.Rules[i], fmt.Sprintf("%s.lookup.rules[%d]", where, i)); err != nil {
+				return err
+			}
+		}
+	}
+	if rule.CountLimit != nil {
+		for i := range rule.CountLimit.Rules {
+			if err := cfg.compileRule(&rule.CountLimit.Rules[i], fmt.Sprintf("%s.countLimit.rules[%d]", where, i)); err != nil {
+				return err
+			}
+		}
+	}
+	if rule.When != nil {
+		if err := cfg.compileRule(rule.When, where+".when"); err != nil {
+			return err
 		}
 	}
+	if cfg.compiler == nil {
+		return nil
+	}
+	if err := cfg.compiler(rule); err != nil {
+		return fmt.Errorf("%s: %v", where, err)
+	}
 	return nil
 }
 
-func (cfg *Config) GetRulesForKind(kind string) []Rule {
+// GetRuleSetsFor returns the rule sets configured for the kind and/or resource and the operation,
+// as they are reported by the admission request, keeping just the rules for the operation
+func (cfg *Config) GetRuleSetsFor(gvk schema.GroupVersionKind, gvr schema.GroupVersionResource, operation string) []ForKindRules {
 	cfg.Lock()
 	defer cfg.Unlock()
-	rulesForKind := []Rule{}
-	rules, found := cfg.cache[kind]
-	if found {
-		for _, rule := range rules {
-			rulesForKind = append(rulesForKind, rule)
+	found := sets.NewInt()
+	for _, gv := range []schema.GroupVersion{
+		gvk.GroupVersion(),
+		{Group: gvk.Group, Version: Any},
+		{Group: Any, Version: gvk.Version},
+		{Group: Any, Version: Any},
+	} {
+		for _, kind := range []string{gvk.Kind, ""} {
+			for _, i := range cfg.cache[gv.WithKind(kind)] {
+				if res := cfg.ForKindsRules[i].Resource; res != "" && res != gvr.Resource {
+					continue
+				}
+				if !hasOperation(cfg.ForKindsRules[i].Operations, operation) {
+					continue
+				}
+				found.Insert(i)
+			}
+		}
+	}
+	// keep the order of the configuration
+	ruleSets := []ForKindRules{}
+	for _, i := range found.List() {
+		ruleSet := cfg.ForKindsRules[i]
+		ruleSet.Rules = []Rule{}
+		for _, rule := range cfg.ForKindsRules[i].Rules {
+			if len(rule.Operations) == 0 || hasOperation(rule.Operations, operation) {
+				ruleSet.Rules = append(ruleSet.Rules, rule)
+			}
 		}
+		if len(ruleSet.Rules) > 0 {
+			ruleSets = append(ruleSets, ruleSet)
+		}
+	}
+	return ruleSets
+}
+
+// GetOperations returns all the operations for which some rule is configured
+func (cfg *Config) GetOperations() []string {
+	cfg.Lock()
+	defer cfg.Unlock()
+	if len(cfg.ForKindsRules) == 0 {
+		return append([]string{}, defaultOperations...)
+	}
+	operations := sets.NewString()
+	for _, k := range cfg.ForKindsRules {
+		operations.Insert(k.Operations...)
 	}
-	return rulesForKind
+	return operations.List()
+}
+
+// GetAllRuleSets returns all the configured rule sets
+func (cfg *Config) GetAllRuleSets() []ForKindRules {
+	cfg.Lock()
+	defer cfg.Unlock()
+	return append([]ForKindRules{}, cfg.ForKindsRules...)
 }
 
 func (cfg *Config) GetAdminGroups() []string {
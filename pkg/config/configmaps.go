@@ -0,0 +1,100 @@
+package config
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ConfigMapRef references a key of a ConfigMap with a list of values, as a yaml list or one per line
+type ConfigMapRef struct {
+	Namespace string `yaml:"namespace"`
+	Name      string `yaml:"name"`
+	Key       string `yaml:"key"`
+}
+
+func (ref *ConfigMapRef) NamespacedName() types.NamespacedName {
+	return types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+}
+
+func (ref *ConfigMapRef) String() string {
+	return ref.NamespacedName().String() + "[" + ref.Key + "]"
+}
+
+// parseValuesList parses a yaml list, or a list of lines skipping the empty ones and the comments
+func parseValuesList(data string) []interface{} {
+	values := []interface{}{}
+	if err := yaml.Unmarshal([]byte(data), &values); err == nil {
+		return values
+	}
+	values = []interface{}{}
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		values = append(values, line)
+	}
+	return values
+}
+
+func (cfg *Config) addConfigMapRef(ref *ConfigMapRef) {
+	if cfg.configMapRefs == nil {
+		cfg.configMapRefs = map[types.NamespacedName]bool{}
+	}
+	cfg.configMapRefs[ref.NamespacedName()] = true
+}
+
+// GetConfigMapRefs returns the ConfigMaps referenced by the rules
+func (cfg *Config) GetConfigMapRefs() []types.NamespacedName {
+	cfg.Lock()
+	defer cfg.Unlock()
+	refs := []types.NamespacedName{}
+	for ref := range cfg.configMapRefs {
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// IsConfigMapReferenced tells if some rule references the ConfigMap
+func (cfg *Config) IsConfigMapReferenced(name types.NamespacedName) bool {
+	cfg.Lock()
+	defer cfg.Unlock()
+	return cfg.configMapRefs[name]
+}
+
+// IsConfigMapLoaded tells if the data of a ConfigMap was set
+func (cfg *Config) IsConfigMapLoaded(name types.NamespacedName) bool {
+	cfg.Lock()
+	defer cfg.Unlock()
+	_, loaded := cfg.configMapValues[name]
+	return loaded
+}
+
+// SetConfigMapData sets the values lists of a referenced ConfigMap, nil data removes them
+// (i.e. the ConfigMap was deleted)
+func (cfg *Config) SetConfigMapData(name types.NamespacedName, data map[string]string) {
+	cfg.Lock()
+	defer cfg.Unlock()
+	if cfg.configMapValues == nil {
+		cfg.configMapValues = map[types.NamespacedName]map[string][]interface{}{}
+	}
+	if data == nil {
+		delete(cfg.configMapValues, name)
+		return
+	}
+	lists := make(map[string][]interface{}, len(data))
+	for key, value := range data {
+		lists[key] = parseValuesList(value)
+	}
+	cfg.configMapValues[name] = lists
+}
+
+// GetConfigMapValues returns the values list in a referenced ConfigMap, if it is loaded and has the key
+func (cfg *Config) GetConfigMapValues(ref *ConfigMapRef) ([]interface{}, bool) {
+	cfg.Lock()
+	defer cfg.Unlock()
+	values, found := cfg.configMapValues[ref.NamespacedName()][ref.Key]
+	return values, found
+}
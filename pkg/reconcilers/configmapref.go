@@ -0,0 +1,56 @@
+package reconcilers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+)
+
+// configMapRefReconciler keeps up to date the values lists in the ConfigMaps referenced by the rules
+type configMapRefReconciler struct {
+	client.Client
+	log logr.Logger
+	cfg *config.Config
+}
+
+func NewConfigMapRefReconciler(log logr.Logger, cfg *config.Config) reconcile.Reconciler {
+	return &configMapRefReconciler{log: log, cfg: cfg}
+}
+
+func (r *configMapRefReconciler) InjectClient(c client.Client) error {
+	r.Client = c
+	return nil
+}
+
+// Implement reconcile.Reconciler so the controller can reconcile objects
+var _ reconcile.Reconciler = &configMapRefReconciler{}
+
+func (r *configMapRefReconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	log := r.log.WithValues("request", request)
+
+	cm := &corev1.ConfigMap{}
+	err := r.Get(context.TODO(), request.NamespacedName, cm)
+	if errors.IsNotFound(err) {
+		// the rules referencing it can't be verified anymore
+		log.Info("Referenced ConfigMap deleted")
+		r.cfg.SetConfigMapData(request.NamespacedName, nil)
+		return reconcile.Result{}, nil
+	}
+
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("could not fetch ConfigMap: %+v", err)
+	}
+
+	log.Info("Reconciling referenced ConfigMap")
+	r.cfg.SetConfigMapData(request.NamespacedName, cm.Data)
+	return reconcile.Result{}, nil
+}
@@ -9,10 +9,12 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/safanaj/k8s-generic-validator/pkg/config"
+	"github.com/safanaj/k8s-generic-validator/pkg/utils/configuration"
 )
 
 const ConfigurationConfigMapKey string = "config.yml"
@@ -21,10 +23,16 @@ type configurationReconciler struct {
 	client.Client
 	log logr.Logger
 	cfg *config.Config
+	// ensureWebhooks registers the webhooks for the operations used in the configuration
+	ensureWebhooks func(operations []string) error
+	// operations are the ones the webhooks are registered for
+	operations []string
 }
 
-func NewConfigurationReconciler(log logr.Logger, cfg *config.Config) reconcile.Reconciler {
-	return &configurationReconciler{log: log, cfg: cfg}
+// NewConfigurationReconciler needs the configuration already loaded, ensureWebhooks is called
+// when the operations used in a new configuration are not the ones the webhooks are registered for
+func NewConfigurationReconciler(log logr.Logger, cfg *config.Config, ensureWebhooks func(operations []string) error) reconcile.Reconciler {
+	return &configurationReconciler{log: log, cfg: cfg, ensureWebhooks: ensureWebhooks, operations: cfg.GetOperations()}
 }
 
 func (r *configurationReconciler) InjectClient(c client.Client) error {
@@ -59,9 +67,27 @@ func (r *configurationReconciler) Reconcile(request reconcile.Request) (reconcil
 		return reconcile.Result{}, fmt.Errorf("ConfigMap is missing required key: %s", ConfigurationConfigMapKey)
 	}
 
+	// rules are compiled (i.e. CEL expressions) parsing the configuration, on errors
+	// the previous configuration is kept
 	if err := r.cfg.ParseYaml([]byte(data)); err != nil {
+		log.Error(err, "Configuration rejected, keeping the previous one")
 		return reconcile.Result{}, fmt.Errorf("ConfigMap is not well formatted: %+v", err)
 	}
 
+	// the ConfigMaps referenced for the first time are not watched yet
+	if err := configuration.LoadReferencedConfigMaps(r.Client, r.cfg); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// the webhooks are registered again when the configuration uses other operations
+	operations := r.cfg.GetOperations()
+	if r.ensureWebhooks != nil && !sets.NewString(operations...).Equal(sets.NewString(r.operations...)) {
+		log.Info("Registering webhooks for the operations", "operations", operations)
+		if err := r.ensureWebhooks(operations); err != nil {
+			return reconcile.Result{}, fmt.Errorf("could not register webhooks: %+v", err)
+		}
+		r.operations = operations
+	}
+
 	return reconcile.Result{}, nil
 }
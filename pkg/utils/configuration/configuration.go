@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -35,8 +36,30 @@ func EnsureFirstConfigurationLoad(namespacedConfigMap string, c client.Reader, c
 			return
 		}
 
-		err = cfg.ParseYaml([]byte(data))
+		if err = cfg.ParseYaml([]byte(data)); err != nil {
+			return
+		}
+		err = LoadReferencedConfigMaps(c, cfg)
 	}
 	firstConfigLoad.Do(onceDo)
 	return err
 }
+
+// LoadReferencedConfigMaps sets the data of the ConfigMaps referenced by the rules and not loaded yet,
+// the missing ones are left not loaded
+func LoadReferencedConfigMaps(c client.Reader, cfg *config.Config) error {
+	for _, name := range cfg.GetConfigMapRefs() {
+		if cfg.IsConfigMapLoaded(name) {
+			continue
+		}
+		cm := &corev1.ConfigMap{}
+		if err := c.Get(context.TODO(), name, cm); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("could not get referenced ConfigMap %s: %v", name, err)
+		}
+		cfg.SetConfigMapData(name, cm.Data)
+	}
+	return nil
+}
@@ -0,0 +1,365 @@
+// Package fieldpath parses paths addressing values into an unstructured object, the syntax is:
+//
+//	metadata.name                              dotted keys
+//	metadata.labels.app\.kubernetes\.io/name   dots escaped in a key
+//	metadata.labels["app.kubernetes.io/name"]  quoted keys (also single quoted)
+//	spec.containers[0].image                   list index
+//	spec.containers[*].image                   any item of a list (or any value of a map)
+//	spec.containers[?(@.name=="app")].image    items matching a filter
+//
+// A filter compares a path relative to the item (@) with == or != to a literal
+// (quoted string, number, true, false or null), without a comparison it checks
+// that the path exists.
+package fieldpath
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type segmentKind int
+
+const (
+	keySegment segmentKind = iota
+	indexSegment
+	wildcardSegment
+	filterSegment
+)
+
+type segment struct {
+	kind   segmentKind
+	key    string
+	index  int
+	filter *filter
+}
+
+type filter struct {
+	path *Path
+	// op is empty when the filter just checks the existence of path
+	op    string
+	value interface{}
+}
+
+type Path struct {
+	raw      string
+	segments []segment
+}
+
+func (p *Path) String() string { return p.raw }
+
+// IsMulti is true when the path can resolve to more than one value
+func (p *Path) IsMulti() bool {
+	for _, seg := range p.segments {
+		if seg.kind == wildcardSegment || seg.kind == filterSegment {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse parses a field path, an empty one addresses the whole object
+func Parse(path string) (*Path, error) {
+	p := &Path{raw: path}
+	i := 0
+	for i < len(path) {
+		switch path[i] {
+		case '[':
+			seg, next, err := parseBracket(path, i)
+			if err != nil {
+				return nil, err
+			}
+			p.segments = append(p.segments, seg)
+			i = next
+		case ']':
+			return nil, fmt.Errorf("invalid field path %q: unexpected ']' at %d", path, i)
+		case '.':
+			if i == 0 || i == len(path)-1 {
+				return nil, fmt.Errorf("invalid field path %q: unexpected '.' at %d", path, i)
+			}
+			i++
+			if path[i] == '.' || path[i] == '[' {
+				return nil, fmt.Errorf("invalid field path %q: empty key at %d", path, i)
+			}
+		default:
+			if i > 0 && path[i-1] == ']' {
+				return nil, fmt.Errorf("invalid field path %q: expected '.' or '[' at %d", path, i)
+			}
+			key, next := parseKey(path, i)
+			if key == "*" {
+				p.segments = append(p.segments, segment{kind: wildcardSegment})
+			} else {
+				p.segments = append(p.segments, segment{kind: keySegment, key: key})
+			}
+			i = next
+		}
+	}
+	return p, nil
+}
+
+// parseKey reads a dotted key up to the next unescaped '.', '[' or ']'
+func parseKey(path string, i int) (string, int) {
+	var b strings.Builder
+	for ; i < len(path); i++ {
+		c := path[i]
+		if c == '\\' && i+1 < len(path) {
+			i++
+			b.WriteByte(path[i])
+			continue
+		}
+		if c == '.' || c == '[' || c == ']' {
+			break
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), i
+}
+
+// parseQuoted reads a string quoted by path[i], returning the index after the closing quote
+func parseQuoted(path string, i int) (string, int, error) {
+	quote := path[i]
+	var b strings.Builder
+	for i++; i < len(path); i++ {
+		c := path[i]
+		if c == '\\' && i+1 < len(path) {
+			i++
+			b.WriteByte(path[i])
+			continue
+		}
+		if c == quote {
+			return b.String(), i + 1, nil
+		}
+		b.WriteByte(c)
+	}
+	return "", i, fmt.Errorf("invalid field path %q: unterminated string", path)
+}
+
+// parseBracket parses what is between path[i] == '[' and the matching ']'
+func parseBracket(path string, i int) (segment, int, error) {
+	start := i
+	i++
+	if i >= len(path) {
+		return segment{}, i, fmt.Errorf("invalid field path %q: unterminated '[' at %d", path, start)
+	}
+	var seg segment
+	switch c := path[i]; {
+	case c == '"' || c == '\'':
+		key, next, err := parseQuoted(path, i)
+		if err != nil {
+			return seg, next, err
+		}
+		seg, i = segment{kind: keySegment, key: key}, next
+	case c == '*':
+		seg, i = segment{kind: wildcardSegment}, i+1
+	case c == '?':
+		f, next, err := parseFilter(path, i+1)
+		if err != nil {
+			return seg, next, err
+		}
+		seg, i = segment{kind: filterSegment, filter: f}, next
+	default:
+		end := strings.IndexByte(path[i:], ']')
+		if end < 0 {
+			return seg, i, fmt.Errorf("invalid field path %q: unterminated '[' at %d", path, start)
+		}
+		index, err := strconv.Atoi(path[i : i+end])
+		if err != nil || index < 0 {
+			return seg, i, fmt.Errorf("invalid field path %q: invalid index %q", path, path[i:i+end])
+		}
+		seg, i = segment{kind: indexSegment, index: index}, i+end
+	}
+	if i >= len(path) || path[i] != ']' {
+		return seg, i, fmt.Errorf("invalid field path %q: expected ']' at %d", path, i)
+	}
+	return seg, i + 1, nil
+}
+
+// parseFilter parses "(@... op literal)" starting at path[i] == '('
+func parseFilter(path string, i int) (*filter, int, error) {
+	if i >= len(path) || path[i] != '(' {
+		return nil, i, fmt.Errorf("invalid field path %q: expected '(' at %d", path, i)
+	}
+	// look for the closing parenthesis out of quoted strings
+	start, end := i+1, -1
+	for j := start; j < len(path) && end < 0; j++ {
+		switch path[j] {
+		case '"', '\'':
+			_, next, err := parseQuoted(path, j)
+			if err != nil {
+				return nil, j, err
+			}
+			j = next - 1
+		case ')':
+			end = j
+		}
+	}
+	if end < 0 {
+		return nil, i, fmt.Errorf("invalid field path %q: unterminated filter at %d", path, i)
+	}
+	expr := strings.TrimSpace(path[start:end])
+	if !strings.HasPrefix(expr, "@") {
+		return nil, i, fmt.Errorf("invalid field path %q: filter has to start with @", path)
+	}
+	f := &filter{}
+	lhs := expr[1:]
+	for _, op := range []string{"==", "!="} {
+		if idx := indexOutOfQuotes(lhs, op); idx >= 0 {
+			value, err := parseLiteral(strings.TrimSpace(lhs[idx+len(op):]))
+			if err != nil {
+				return nil, i, fmt.Errorf("invalid field path %q: %v", path, err)
+			}
+			f.op, f.value, lhs = op, value, lhs[:idx]
+			break
+		}
+	}
+	sub, err := Parse(strings.TrimPrefix(strings.TrimSpace(lhs), "."))
+	if err != nil {
+		return nil, i, err
+	}
+	f.path = sub
+	return f, end + 1, nil
+}
+
+func indexOutOfQuotes(s, sub string) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0 && s[i] == '\\':
+			i++
+		case quote != 0 && s[i] == quote:
+			quote = 0
+		case quote == 0 && (s[i] == '"' || s[i] == '\''):
+			quote = s[i]
+		case quote == 0 && strings.HasPrefix(s[i:], sub):
+			return i
+		}
+	}
+	return -1
+}
+
+func parseLiteral(s string) (interface{}, error) {
+	if len(s) > 0 && (s[0] == '"' || s[0] == '\'') {
+		value, next, err := parseQuoted(s, 0)
+		if err != nil || next != len(s) {
+			return nil, fmt.Errorf("invalid string literal %s", s)
+		}
+		return value, nil
+	}
+	switch s {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("invalid literal %s", s)
+}
+
+// Resolve returns all the values the path addresses into obj, none if it is missing
+func (p *Path) Resolve(obj interface{}) []interface{} {
+	current := []interface{}{obj}
+	for _, seg := range p.segments {
+		next := []interface{}{}
+		for _, value := range current {
+			next = append(next, seg.resolve(value)...)
+		}
+		if len(next) == 0 {
+			return nil
+		}
+		current = next
+	}
+	return current
+}
+
+func (seg segment) resolve(value interface{}) []interface{} {
+	switch seg.kind {
+	case keySegment:
+		if m, ok := value.(map[string]interface{}); ok {
+			if v, found := m[seg.key]; found {
+				return []interface{}{v}
+			}
+		}
+	case indexSegment:
+		if l, ok := value.([]interface{}); ok && seg.index < len(l) {
+			return []interface{}{l[seg.index]}
+		}
+	case wildcardSegment:
+		return items(value)
+	case filterSegment:
+		matching := []interface{}{}
+		for _, item := range items(value) {
+			if seg.filter.matches(item) {
+				matching = append(matching, item)
+			}
+		}
+		return matching
+	}
+	return nil
+}
+
+// items are the items of a list or the values of a map, sorted by key
+func items(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		values := make([]interface{}, 0, len(v))
+		for _, k := range keys {
+			values = append(values, v[k])
+		}
+		return values
+	}
+	return nil
+}
+
+func (f *filter) matches(item interface{}) bool {
+	values := f.path.Resolve(item)
+	if f.op == "" {
+		return len(values) > 0
+	}
+	equal := false
+	for _, value := range values {
+		if literalEqual(value, f.value) {
+			equal = true
+			break
+		}
+	}
+	if f.op == "!=" {
+		return !equal
+	}
+	return equal
+}
+
+// literalEqual compares numbers regardless of them being int64 or float64
+func literalEqual(a, b interface{}) bool {
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		return ok && af == bf
+	}
+	return a == b
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
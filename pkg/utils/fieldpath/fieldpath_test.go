@@ -0,0 +1,87 @@
+package fieldpath
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseResolve(t *testing.T) {
+	obj := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "web",
+			"labels": map[string]interface{}{
+				"app.kubernetes.io/name": "web",
+				"team":                   "a",
+			},
+		},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "app", "image": "app:1", "port": int64(80)},
+				map[string]interface{}{"name": "side)car", "image": "sidecar:2", "port": 8080.0},
+			},
+		},
+	}
+
+	tests := []struct {
+		path   string
+		want   []interface{}
+		multi  bool
+		errors bool
+	}{
+		{path: "", want: []interface{}{obj}},
+		{path: "metadata.name", want: []interface{}{"web"}},
+		{path: "metadata.missing"},
+		{path: `metadata.labels.app\.kubernetes\.io/name`, want: []interface{}{"web"}},
+		{path: `metadata.labels["app.kubernetes.io/name"]`, want: []interface{}{"web"}},
+		{path: `metadata.labels['app.kubernetes.io/name']`, want: []interface{}{"web"}},
+		{path: `metadata.labels["quoted \"key\""]`},
+		{path: "spec.containers[1].image", want: []interface{}{"sidecar:2"}},
+		{path: "spec.containers[2].image"},
+		{path: "spec.containers[*].name", want: []interface{}{"app", "side)car"}, multi: true},
+		{path: "metadata.labels.*", want: []interface{}{"web", "a"}, multi: true},
+		{path: `spec.containers[?(@.name=="side)car")].image`, want: []interface{}{"sidecar:2"}, multi: true},
+		{path: `spec.containers[?(@.name!='side)car')].image`, want: []interface{}{"app:1"}, multi: true},
+		{path: "spec.containers[?(@.port==8080)].name", want: []interface{}{"side)car"}, multi: true},
+		{path: "spec.containers[?(@.port==80)].name", want: []interface{}{"app"}, multi: true},
+		{path: "spec.containers[?(@.missing)].name", multi: true},
+		{path: "a]", errors: true},
+		{path: "a]b", errors: true},
+		{path: "]", errors: true},
+		{path: "a[0]]", errors: true},
+		{path: "a[0]b", errors: true},
+		{path: "a[0", errors: true},
+		{path: "a[", errors: true},
+		{path: "a[-1]", errors: true},
+		{path: "a[x]", errors: true},
+		{path: `a["b]`, errors: true},
+		{path: `a["b"`, errors: true},
+		{path: "a[?(@.b==1]", errors: true},
+		{path: `a[?(@.b=="c)]`, errors: true},
+		{path: "a[?(b==1)]", errors: true},
+		{path: "a[?(@.b==c)]", errors: true},
+		{path: ".a", errors: true},
+		{path: "a.", errors: true},
+		{path: "a..b", errors: true},
+		{path: "a.[0]", errors: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			p, err := Parse(tt.path)
+			if tt.errors {
+				if err == nil {
+					t.Fatalf("Parse(%q) did not fail", tt.path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.path, err)
+			}
+			if p.IsMulti() != tt.multi {
+				t.Errorf("Parse(%q).IsMulti() = %v, want %v", tt.path, p.IsMulti(), tt.multi)
+			}
+			if got := p.Resolve(obj); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q).Resolve() = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -41,3 +41,18 @@ func GetCertificateSecretPredicates(secNamed types.NamespacedName) predicate.Fun
 		DeleteFunc: func(e event.DeleteEvent) bool { return false },
 	}
 }
+
+// GetReferencedConfigMapPredicates selects the ConfigMaps referenced by the rules, also on delete
+func GetReferencedConfigMapPredicates(isReferenced func(types.NamespacedName) bool) predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return isReferenced(types.NamespacedName{Namespace: e.Meta.GetNamespace(), Name: e.Meta.GetName()})
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return isReferenced(types.NamespacedName{Namespace: e.MetaNew.GetNamespace(), Name: e.MetaNew.GetName()})
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return isReferenced(types.NamespacedName{Namespace: e.Meta.GetNamespace(), Name: e.Meta.GetName()})
+		},
+	}
+}
@@ -19,13 +19,15 @@ const (
 )
 
 // needs refactoring to build this at runtime based on configuration
-func getRules() []ar.RuleWithOperations {
+func getRules(operations []string) []ar.RuleWithOperations {
 	scope := ar.NamespacedScope
+	ops := []ar.OperationType{}
+	for _, op := range operations {
+		ops = append(ops, ar.OperationType(op))
+	}
 	return []ar.RuleWithOperations{
 		{
-			Operations: []ar.OperationType{
-				ar.Create, ar.Update,
-			},
+			Operations: ops,
 			Rule: ar.Rule{
 				APIGroups:   []string{""},
 				APIVersions: []string{"v1"},
@@ -41,9 +43,10 @@ func getServiceNamespacedName(name string) types.NamespacedName {
 	return types.NamespacedName{parts[0], parts[1]}
 }
 
+// operations are the ones the webhooks are registered for, all those used in the configuration
 func EnsureWebhookConfigurations(
 	serviceName, webhookCertificate, validating, mutating string,
-	enableValidating, enableMutating bool,
+	enableValidating, enableMutating bool, operations []string,
 	r client.Reader, c client.Client) error {
 	svcNamed := getServiceNamespacedName(serviceName)
 	vPath := ValidatingPath
@@ -75,7 +78,7 @@ func EnsureWebhookConfigurations(
 							Port:      &port_,
 						},
 					},
-					Rules:       getRules(),
+					Rules:       getRules(operations),
 					MatchPolicy: &matchPolicy,
 					SideEffects: &sideEffects,
 				},
@@ -85,14 +88,34 @@ func EnsureWebhookConfigurations(
 				return err
 			}
 		}
-		// already exists, keep it untouched
+		// already exists, the webhook is updated in place so the CA bundle injected by cert-manager,
+		// the fields defaulted by the api server and the other webhooks are kept
 
-		whc.ObjectMeta.Annotations = map[string]string{
-			"cert-manager.io/inject-ca-from": webhookCertificate,
+		if whc.ObjectMeta.Annotations == nil {
+			whc.ObjectMeta.Annotations = map[string]string{}
+		}
+		whc.ObjectMeta.Annotations["cert-manager.io/inject-ca-from"] = webhookCertificate
+		name := strings.Join([]string{"validate", validating, "aureacentral", "com"}, ".")
+		found := false
+		for i := range whc.Webhooks {
+			if whc.Webhooks[i].Name != name {
+				continue
+			}
+			found = true
+			wh := &whc.Webhooks[i]
+			wh.ClientConfig.Service = &ar.ServiceReference{
+				Namespace: svcNamed.Namespace,
+				Name:      svcNamed.Name,
+				Path:      &vPath,
+				Port:      &port_,
+			}
+			wh.Rules = getRules(operations)
+			wh.MatchPolicy = &matchPolicy
+			wh.SideEffects = &sideEffects
 		}
-		whc.Webhooks = []ar.ValidatingWebhook{
-			{
-				Name: strings.Join([]string{"validate", validating, "aureacentral", "com"}, "."),
+		if !found {
+			whc.Webhooks = append(whc.Webhooks, ar.ValidatingWebhook{
+				Name: name,
 				ClientConfig: ar.WebhookClientConfig{
 					Service: &ar.ServiceReference{
 						Namespace: svcNamed.Namespace,
@@ -101,10 +124,10 @@ func EnsureWebhookConfigurations(
 						Port:      &port_,
 					},
 				},
-				Rules:       getRules(),
+				Rules:       getRules(operations),
 				MatchPolicy: &matchPolicy,
 				SideEffects: &sideEffects,
-			},
+			})
 		}
 
 		if err := c.Update(context.TODO(), whc); err != nil {
@@ -135,7 +158,7 @@ func EnsureWebhookConfigurations(
 							Port:      &port_,
 						},
 					},
-					Rules:       getRules(),
+					Rules:       getRules(operations),
 					MatchPolicy: &matchPolicy,
 					SideEffects: &sideEffects,
 				},
@@ -146,12 +169,32 @@ func EnsureWebhookConfigurations(
 			}
 		}
 
-		whc.ObjectMeta.Annotations = map[string]string{
-			"cert-manager.io/inject-ca-from": webhookCertificate,
+		// as for the validating one, the webhook is updated in place
+		if whc.ObjectMeta.Annotations == nil {
+			whc.ObjectMeta.Annotations = map[string]string{}
 		}
-		whc.Webhooks = []ar.MutatingWebhook{
-			{
-				Name: strings.Join([]string{"mutate", mutating, "aureacentral", "com"}, "."),
+		whc.ObjectMeta.Annotations["cert-manager.io/inject-ca-from"] = webhookCertificate
+		name := strings.Join([]string{"mutate", mutating, "aureacentral", "com"}, ".")
+		found := false
+		for i := range whc.Webhooks {
+			if whc.Webhooks[i].Name != name {
+				continue
+			}
+			found = true
+			wh := &whc.Webhooks[i]
+			wh.ClientConfig.Service = &ar.ServiceReference{
+				Namespace: svcNamed.Namespace,
+				Name:      svcNamed.Name,
+				Path:      &mPath,
+				Port:      &port_,
+			}
+			wh.Rules = getRules(operations)
+			wh.MatchPolicy = &matchPolicy
+			wh.SideEffects = &sideEffects
+		}
+		if !found {
+			whc.Webhooks = append(whc.Webhooks, ar.MutatingWebhook{
+				Name: name,
 				ClientConfig: ar.WebhookClientConfig{
 					Service: &ar.ServiceReference{
 						Namespace: svcNamed.Namespace,
@@ -160,10 +203,10 @@ func EnsureWebhookConfigurations(
 						Port:      &port_,
 					},
 				},
-				Rules:       getRules(),
+				Rules:       getRules(operations),
 				MatchPolicy: &matchPolicy,
 				SideEffects: &sideEffects,
-			},
+			})
 		}
 
 		if err := c.Update(context.TODO(), whc); err != nil {
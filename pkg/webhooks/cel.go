@@ -0,0 +1,92 @@
+package webhooks
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+)
+
+// variables available to the CEL expressions
+const (
+	celVarObject          = "object"
+	celVarOldObject       = "oldObject"
+	celVarRequest         = "request"
+	celVarNamespaceObject = "namespaceObject"
+)
+
+var celEnvOnce sync.Once
+var celEnv *cel.Env
+var celEnvErr error
+
+func getCELEnv() (*cel.Env, error) {
+	celEnvOnce.Do(func() {
+		celEnv, celEnvErr = cel.NewEnv(
+			cel.Variable(celVarObject, cel.DynType),
+			cel.Variable(celVarOldObject, cel.DynType),
+			cel.Variable(celVarRequest, cel.DynType),
+			cel.Variable(celVarNamespaceObject, cel.DynType),
+		)
+	})
+	return celEnv, celEnvErr
+}
+
+// compileCEL compiles an expression that has to evaluate to a bool
+func compileCEL(expression string) (cel.Program, error) {
+	env, err := getCELEnv()
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("invalid CEL expression: %v", issues.Err())
+	}
+	if t := ast.OutputType(); t != cel.BoolType && t != cel.DynType {
+		return nil, fmt.Errorf("CEL expression has type %v instead of bool", t)
+	}
+	return env.Program(ast)
+}
+
+// lazyValue returns a CEL lazily evaluated variable, errors evaluate to CEL errors
+func lazyValue(get func() (map[string]interface{}, error)) func() ref.Val {
+	return func() ref.Val {
+		value, err := get()
+		if err != nil {
+			return types.NewErr(err.Error())
+		}
+		if value == nil {
+			return types.NullValue
+		}
+		return types.DefaultTypeAdapter.NativeToValue(value)
+	}
+}
+
+func (v *genericValidator) verifyCEL(rc *ruleContext, rule config.Rule) (bool, error) {
+	compiled, err := getCompiled(rule)
+	if err != nil {
+		return false, err
+	}
+	// a nil map would be an empty one and not null
+	var oldObject interface{}
+	if rc.oldObject != nil {
+		oldObject = rc.oldObject
+	}
+	out, _, err := compiled.program.Eval(map[string]interface{}{
+		celVarObject:          rc.object,
+		celVarOldObject:       oldObject,
+		celVarRequest:         lazyValue(rc.requestObject),
+		celVarNamespaceObject: lazyValue(rc.namespaceObject),
+	})
+	if err != nil {
+		return false, fmt.Errorf("CEL evaluation failed: %v", err)
+	}
+	result, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("CEL expression evaluated to %v instead of a bool", out.Value())
+	}
+	return result, nil
+}
@@ -0,0 +1,148 @@
+package webhooks
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+)
+
+// oldPrefix makes a field path (or a valueFrom one) resolve against the old object
+const oldPrefix = "old."
+
+// changeOperators compare the field with its value in the old object
+var changeOperators = sets.NewString(
+	OperatorChanged, OperatorUnchanged, OperatorIncreased, OperatorDecreased, OperatorAllowedTransitions)
+
+// splitOldPrefix returns the field path without the old prefix, and if it had it
+func splitOldPrefix(field string) (string, bool) {
+	if strings.HasPrefix(field, oldPrefix) {
+		return strings.TrimPrefix(field, oldPrefix), true
+	}
+	return field, false
+}
+
+// usesOldObject is true when the field, the valueFrom field or a template of the rule value
+// are into the old object
+func (c *compiledRule) usesOldObject() bool {
+	if c.old || c.valueFromOld {
+		return true
+	}
+	for _, t := range c.valueTemplates {
+		for _, part := range t.parts {
+			if part.root == "oldObject" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compileTransitions parses the value of AllowedTransitions, a map from the old value
+// to the new values it can change to
+func compileTransitions(rule *config.Rule) (map[string]sets.String, error) {
+	value, ok := rule.Value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Value (of type %T) in rule is not a map of transitions", rule.Value)
+	}
+	transitions := make(map[string]sets.String, len(value))
+	for from, to := range value {
+		values, ok := toStringSlice(to)
+		if !ok {
+			return nil, fmt.Errorf("transitions from %s (of type %T) are not a string nor a list of strings", from, to)
+		}
+		transitions[from] = sets.NewString(values...)
+	}
+	return transitions, nil
+}
+
+// compileChange validates a rule with a change operator
+func compileChange(rule *config.Rule, compiled *compiledRule) error {
+	if compiled.old {
+		return fmt.Errorf("Operator %s can't be used with a field of the old object", rule.Op)
+	}
+	switch rule.Op {
+	case OperatorIncreased, OperatorDecreased:
+		if compiled.path.IsMulti() {
+			return fmt.Errorf("Operator %s needs a field path addressing a single value", rule.Op)
+		}
+		_, isOrdered := orderedTypes[rule.Type]
+		switch {
+		case isOrdered, rule.Type == ValueTypeInt, rule.Type == ValueTypeInt64,
+			rule.Type == ValueTypeFloat, rule.Type == ValueTypeFloat64:
+		default:
+			return fmt.Errorf("Operator %s needs a numeric or ordered type", rule.Op)
+		}
+	case OperatorAllowedTransitions:
+		if compiled.path.IsMulti() {
+			return fmt.Errorf("Operator %s needs a field path addressing a single value", rule.Op)
+		}
+		transitions, err := compileTransitions(rule)
+		if err != nil {
+			return err
+		}
+		compiled.transitions = transitions
+	}
+	return nil
+}
+
+// verifyChange verifies the change operators, they do not apply when there is no old object
+// (i.e. on CREATE) or the field was not set in it
+func (v *genericValidator) verifyChange(rc *ruleContext, rule config.Rule, compiled *compiledRule) (bool, error) {
+	if rc.oldObject == nil {
+		return false, errNotApplicable
+	}
+	oldValues := compiled.path.Resolve(rc.oldObject)
+	if len(oldValues) == 0 {
+		return false, errNotApplicable
+	}
+	values := compiled.path.Resolve(rc.object)
+	switch rule.Op {
+	case OperatorChanged:
+		return !reflect.DeepEqual(values, oldValues), nil
+	case OperatorUnchanged:
+		return reflect.DeepEqual(values, oldValues), nil
+	}
+	if len(values) == 0 {
+		if rule.OnMissing == OnMissingAllow {
+			return true, nil
+		}
+		return false, &missingValueError{field: rule.Field}
+	}
+	switch rule.Op {
+	case OperatorIncreased, OperatorDecreased:
+		// the value is compared with the old one as the rule value, an unchanged one holds
+		compared := rule
+		compared.Op, compared.Value, compared.Compiled = OperatorEqualOrMoreThan, oldValues[0], nil
+		if rule.Op == OperatorDecreased {
+			compared.Op = OperatorEqualOrLessThan
+		}
+		if err := compileRule(&compared, false); err != nil {
+			return false, fmt.Errorf("old value of %s: %v", rule.Field, err)
+		}
+		return v.verifyValue(values[0], compared, compared.Compiled.(*compiledRule))
+	case OperatorAllowedTransitions:
+		from, ok := oldValues[0].(string)
+		if !ok {
+			return false, fmt.Errorf("%v accessor error: old value %v is of the type %T, expected string",
+				rule.Field, oldValues[0], oldValues[0])
+		}
+		to, ok := values[0].(string)
+		if !ok {
+			return false, fmt.Errorf("%v accessor error: %v is of the type %T, expected string",
+				rule.Field, values[0], values[0])
+		}
+		if from == to {
+			return true, nil
+		}
+		allowed, found := compiled.transitions[from]
+		if !found {
+			allowed = compiled.transitions[config.Any]
+		}
+		return allowed.Has(to) || allowed.Has(config.Any), nil
+	}
+	return false, fmt.Errorf("unknown operator %s", rule.Op)
+}
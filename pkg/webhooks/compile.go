@@ -0,0 +1,421 @@
+package webhooks
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/google/cel-go/cel"
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+	"github.com/safanaj/k8s-generic-validator/pkg/utils/fieldpath"
+)
+
+// compiledRule is what CompileRule prepares once for a rule
+type compiledRule struct {
+	path *fieldpath.Path
+	// program is set for the cel rules
+	program cel.Program
+	// values are the parsed rule values for the ordered types
+	values []interface{}
+	// constraint is set for the semver range operators
+	constraint *semver.Constraints
+	// networks are the rule values for the ip and cidr types
+	networks []*net.IPNet
+	// length is the rule value for the length operators
+	length int64
+	// imageValue is the rule value for the image type, normalized for the whole reference
+	imageValue interface{}
+	// valueFrom is the path of the field the rule value is taken from
+	valueFrom *fieldpath.Path
+	// old is set when the field path (with the old. prefix) is into the old object
+	old bool
+	// valueTemplates are the rule value (a string or a list) when it has templates
+	valueTemplates []*valueTemplate
+	// valueFromOld is set when the valueFrom field path is into the old object
+	valueFromOld bool
+	// transitions are the rule value for AllowedTransitions
+	transitions map[string]sets.String
+	// lookup is set for the rules of type lookup
+	lookup *compiledLookup
+	// countLimit is set for the rules of type countLimit
+	countLimit *compiledCountLimit
+	// schema is set for the rules of type jsonSchema
+	schema *gojsonschema.Schema
+	// defaultValue is the rule Default as if it was decoded from json
+	defaultValue interface{}
+	// patterns are set for the pattern matching operators
+	patterns []*regexp.Regexp
+}
+
+// patternOperators maps the pattern matching operators to the func building the
+// regular expression for a value and to their negated form
+var patternOperators = map[Operator]struct {
+	toRegex func(string) string
+	negated bool
+}{
+	OperatorRegex:         {func(s string) string { return s }, false},
+	OperatorNotRegex:      {func(s string) string { return s }, true},
+	OperatorGlob:          {globToRegex, false},
+	OperatorNotGlob:       {globToRegex, true},
+	OperatorStartsWith:    {func(s string) string { return "^" + regexp.QuoteMeta(s) }, false},
+	OperatorNotStartsWith: {func(s string) string { return "^" + regexp.QuoteMeta(s) }, true},
+	OperatorEndsWith:      {func(s string) string { return regexp.QuoteMeta(s) + "$" }, false},
+	OperatorNotEndsWith:   {func(s string) string { return regexp.QuoteMeta(s) + "$" }, true},
+	OperatorContains:      {regexp.QuoteMeta, false},
+	OperatorNotContains:   {regexp.QuoteMeta, true},
+}
+
+var (
+	equalityOperators = sets.NewString(OperatorIs, OperatorIsNot, OperatorIn, OperatorNotIn)
+	orderingOperators = sets.NewString(
+		OperatorGreaterThan, OperatorMoreThan, OperatorSmallerThan, OperatorLessThan,
+		OperatorEqualOrGreaterThan, OperatorEqualOrMoreThan, OperatorEqualOrSmallerThan, OperatorEqualOrLessThan)
+	stringOperators = equalityOperators.Union(sets.NewString(
+		OperatorRegex, OperatorNotRegex, OperatorGlob, OperatorNotGlob,
+		OperatorStartsWith, OperatorNotStartsWith, OperatorEndsWith, OperatorNotEndsWith,
+		OperatorContains, OperatorNotContains))
+	numericOperators = equalityOperators.Union(orderingOperators)
+	sliceOperators   = sets.NewString(
+		OperatorIs, OperatorIsNot, OperatorContainsAll, OperatorContainsAny, OperatorSubsetOf, OperatorDisjoint)
+)
+
+// typeOperators maps the value types to the operators they are verified with, the ones of
+// anyTypeOperators are also for the rules without a type
+var typeOperators = map[ValueType]sets.String{
+	ValueTypeString:       stringOperators,
+	ValueTypeImage:        stringOperators,
+	ValueTypeBool:         sets.NewString(OperatorIs, OperatorIsNot),
+	ValueTypeInt:          numericOperators,
+	ValueTypeInt64:        numericOperators,
+	ValueTypeFloat:        numericOperators,
+	ValueTypeFloat64:      numericOperators,
+	ValueTypeQuantity:     numericOperators,
+	ValueTypeDuration:     numericOperators,
+	ValueTypeTimestamp:    numericOperators,
+	ValueTypeSemver:       numericOperators.Union(sets.NewString(OperatorInRange, OperatorNotInRange)),
+	ValueTypeIntOrString:  numericOperators,
+	ValueTypeObject:       sets.NewString(OperatorIs, OperatorIsNot),
+	ValueTypeIP:           equalityOperators.Union(sets.NewString(OperatorInCIDR, OperatorNotInCIDR, OperatorOverlaps, OperatorNotOverlaps)),
+	ValueTypeCIDR:         equalityOperators.Union(sets.NewString(OperatorInCIDR, OperatorNotInCIDR, OperatorOverlaps, OperatorNotOverlaps)),
+	ValueTypeStringSlice:  sliceOperators,
+	ValueTypeBoolSlice:    sliceOperators,
+	ValueTypeIntSlice:     sliceOperators,
+	ValueTypeInt64Slice:   sliceOperators,
+	ValueTypeFloatSlice:   sliceOperators,
+	ValueTypeFloat64Slice: sliceOperators,
+}
+
+// anyTypeOperators are verified whatever the type is, the change ones check it on their own
+var anyTypeOperators = sets.NewString(OperatorExists, OperatorNotExists).
+	Union(mapOperators).Union(changeOperators).
+	Insert(OperatorLengthIs, OperatorMinLength, OperatorMaxLength)
+
+// checkOperator verifies that the type of the rule is known and that the operator is one
+// the type is verified with, so a rule that can't be verified is rejected loading the configuration
+func checkOperator(rule *config.Rule) error {
+	operators, known := typeOperators[rule.Type]
+	if !known && rule.Type != "" {
+		return fmt.Errorf("unknown type: %s", rule.Type)
+	}
+	if rule.Op == "" {
+		return fmt.Errorf("a rule of type %s needs an operator", rule.Type)
+	}
+	if anyTypeOperators.Has(rule.Op) || operators.Has(rule.Op) {
+		return nil
+	}
+	for _, operators := range typeOperators {
+		if operators.Has(rule.Op) {
+			if rule.Type == "" {
+				return fmt.Errorf("Operator %s needs a type", rule.Op)
+			}
+			return fmt.Errorf("Operator %s can't be used with type %s", rule.Op, rule.Type)
+		}
+	}
+	return fmt.Errorf("unknown operator: %s", rule.Op)
+}
+
+// globToRegex converts a glob pattern, where * matches any sequence of characters
+// (/ included) and ? any single character
+func globToRegex(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// normalizeYamlValue converts the integers decoded by yaml to int64, as they are decoded from json
+func normalizeYamlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case []interface{}:
+		normalized := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			normalized = append(normalized, normalizeYamlValue(item))
+		}
+		return normalized
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			normalized[key] = normalizeYamlValue(item)
+		}
+		return normalized
+	}
+	return value
+}
+
+// toStringSlice accepts a string or a list of strings
+func toStringSlice(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, true
+	case []string:
+		return v, true
+	case []interface{}:
+		values := []string{}
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, s)
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+// CompileRule is the config.RuleCompiler of the genericValidator
+func CompileRule(rule *config.Rule) error {
+	return compileRule(rule, true)
+}
+
+// compileRule compiles a rule, with templates the value is compiled once resolved, at admission time
+func compileRule(rule *config.Rule, templates bool) error {
+	compiled := &compiledRule{}
+	groups := 0
+	for _, isGroup := range []bool{len(rule.AllOf) > 0, len(rule.AnyOf) > 0, rule.Not != nil} {
+		if isGroup {
+			groups++
+		}
+	}
+	if groups > 1 || (groups == 1 && (rule.Field != "" || rule.Op != "")) {
+		return fmt.Errorf("a rule has to be just one of allOf, anyOf, not or a field rule")
+	}
+	if groups == 1 {
+		rule.Compiled = compiled
+		return nil
+	}
+	if rule.Type == RuleTypeCEL {
+		if rule.Expression == "" {
+			return fmt.Errorf("a rule of type %s needs an expression", RuleTypeCEL)
+		}
+		program, err := compileCEL(rule.Expression)
+		if err != nil {
+			return err
+		}
+		compiled.program = program
+		rule.Compiled = compiled
+		return nil
+	}
+	if rule.Type == RuleTypeCountLimit {
+		countLimit, err := compileCountLimit(rule)
+		if err != nil {
+			return err
+		}
+		compiled.countLimit = countLimit
+		rule.Compiled = compiled
+		return nil
+	}
+	if rule.Type == RuleTypeLookup {
+		lookup, err := compileLookup(rule)
+		if err != nil {
+			return err
+		}
+		compiled.lookup = lookup
+		rule.Compiled = compiled
+		return nil
+	}
+	field, old := splitOldPrefix(rule.Field)
+	path, err := fieldpath.Parse(field)
+	if err != nil {
+		return err
+	}
+	compiled.path, compiled.old = path, old
+	if rule.Type == RuleTypeJSONSchema {
+		if compiled.schema, err = compileJSONSchema(rule); err != nil {
+			return err
+		}
+		rule.Compiled = compiled
+		return nil
+	}
+	if rule.Type == RuleTypeUnique {
+		switch rule.Scope {
+		case "", ScopeCluster, ScopeNamespace:
+		default:
+			return fmt.Errorf("unknown scope: %s", rule.Scope)
+		}
+		if compiled.old {
+			return fmt.Errorf("a rule of type %s can't use a field of the old object", RuleTypeUnique)
+		}
+		rule.Compiled = compiled
+		return nil
+	}
+	switch rule.Quantifier {
+	case "", QuantifierAll, QuantifierAny, QuantifierNone:
+	default:
+		return fmt.Errorf("unknown quantifier: %s", rule.Quantifier)
+	}
+	for _, policy := range []MissingPolicy{rule.OnMissing, rule.OnInvalid} {
+		switch policy {
+		case "", OnMissingDeny, OnMissingAllow:
+		case OnMissingDefault:
+			if rule.Default == nil {
+				return fmt.Errorf("policy %s needs a default value", OnMissingDefault)
+			}
+			compiled.defaultValue = normalizeYamlValue(rule.Default)
+		default:
+			return fmt.Errorf("unknown onMissing/onInvalid policy: %s", policy)
+		}
+	}
+	if err := checkOperator(rule); err != nil {
+		return err
+	}
+	if changeOperators.Has(rule.Op) {
+		if err := compileChange(rule, compiled); err != nil {
+			return err
+		}
+		rule.Compiled = compiled
+		return nil
+	}
+	// the value is known at admission time, it is compiled then
+	if templates && hasTemplates(rule.Value) {
+		if compiled.valueTemplates, err = compileValueTemplates(rule.Value); err != nil {
+			return err
+		}
+		rule.Compiled = compiled
+		return nil
+	}
+	if rule.ValueFrom != nil {
+		if rule.Value != nil {
+			return fmt.Errorf("a rule can't have both value and valueFrom")
+		}
+		if ref := rule.ValueFrom.ConfigMapRef; ref != nil {
+			if rule.ValueFrom.Field != "" {
+				return fmt.Errorf("valueFrom has to be just one of field or configMapRef")
+			}
+			if rule.Op != OperatorIn && rule.Op != OperatorNotIn {
+				return fmt.Errorf("valueFrom configMapRef needs Operator %s or %s", OperatorIn, OperatorNotIn)
+			}
+			if ref.Namespace == "" || ref.Name == "" || ref.Key == "" {
+				return fmt.Errorf("valueFrom configMapRef needs namespace, name and key")
+			}
+			rule.Compiled = compiled
+			return nil
+		}
+		var valueFrom string
+		valueFrom, compiled.valueFromOld = splitOldPrefix(rule.ValueFrom.Field)
+		if compiled.valueFrom, err = fieldpath.Parse(valueFrom); err != nil {
+			return fmt.Errorf("valueFrom: %v", err)
+		}
+		if compiled.valueFrom.IsMulti() {
+			return fmt.Errorf("valueFrom field %s has to address a single value", rule.ValueFrom.Field)
+		}
+		rule.Compiled = compiled
+		return nil
+	}
+	if mapOperators.Has(rule.Op) {
+		if err := compileMapRule(rule, compiled); err != nil {
+			return err
+		}
+		rule.Compiled = compiled
+		return nil
+	}
+	if _, isLength := lengthOperators[rule.Op]; isLength {
+		if compiled.length, err = compileLength(rule); err != nil {
+			return err
+		}
+		rule.Compiled = compiled
+		return nil
+	}
+	if patternOp, found := patternOperators[rule.Op]; found {
+		if rule.Type != ValueTypeString && rule.Type != ValueTypeImage {
+			return fmt.Errorf("Operator %s needs type %s or %s", rule.Op, ValueTypeString, ValueTypeImage)
+		}
+		values, ok := toStringSlice(rule.Value)
+		if !ok {
+			return fmt.Errorf("Value (of type %T) in rule is not a string nor a list of strings", rule.Value)
+		}
+		for _, value := range values {
+			expr := patternOp.toRegex(value)
+			if rule.IgnoreCase {
+				expr = "(?i)" + expr
+			}
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return fmt.Errorf("invalid pattern %q: %v", value, err)
+			}
+			compiled.patterns = append(compiled.patterns, re)
+		}
+	}
+	if rule.Type == ValueTypeImage {
+		if compiled.imageValue, err = compileImage(rule); err != nil {
+			return err
+		}
+	}
+	if (rule.Type == ValueTypeIP || rule.Type == ValueTypeCIDR) &&
+		rule.Op != OperatorExists && rule.Op != OperatorNotExists {
+		if compiled.networks, err = compileNetworks(rule); err != nil {
+			return err
+		}
+	}
+	if rule.Op == OperatorInRange || rule.Op == OperatorNotInRange {
+		if rule.Type != ValueTypeSemver {
+			return fmt.Errorf("Operator %s needs type %s", rule.Op, ValueTypeSemver)
+		}
+		value, ok := rule.Value.(string)
+		if !ok {
+			return fmt.Errorf("Value (of type %T) in rule is not a string", rule.Value)
+		}
+		if compiled.constraint, err = semver.NewConstraint(value); err != nil {
+			return fmt.Errorf("invalid semver range %q: %v", value, err)
+		}
+	} else if ot, found := orderedTypes[rule.Type]; found && rule.Op != OperatorExists && rule.Op != OperatorNotExists {
+		if compiled.values, err = compileOrdered(rule, ot); err != nil {
+			return err
+		}
+	} else if rule.Type == ValueTypeIntOrString && rule.Op != OperatorExists && rule.Op != OperatorNotExists {
+		if compiled.values, err = compileIntOrString(rule); err != nil {
+			return err
+		}
+	}
+	rule.Compiled = compiled
+	return nil
+}
+
+// getCompiled returns what CompileRule prepared, compiling the rule if it was not
+func getCompiled(rule config.Rule) (*compiledRule, error) {
+	if compiled, ok := rule.Compiled.(*compiledRule); ok {
+		return compiled, nil
+	}
+	if err := CompileRule(&rule); err != nil {
+		return nil, err
+	}
+	return rule.Compiled.(*compiledRule), nil
+}
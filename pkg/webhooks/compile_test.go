@@ -0,0 +1,51 @@
+package webhooks
+
+import (
+	"testing"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+)
+
+func TestCompileRule(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   config.Rule
+		errors bool
+	}{
+		{name: "string", rule: config.Rule{Field: "spec.type", Type: "string", Op: "Is", Value: "ClusterIP"}},
+		{name: "pattern", rule: config.Rule{Field: "spec.type", Type: "string", Op: "StartsWith", Value: "Cluster"}},
+		{name: "int ordering", rule: config.Rule{Field: "spec.replicas", Type: "int", Op: "LessThan", Value: 10}},
+		{name: "exists without type", rule: config.Rule{Field: "spec.type", Op: "Exists"}},
+		{name: "length without type", rule: config.Rule{Field: "spec.ports", Op: "MaxLength", Value: 3}},
+		{name: "map without type", rule: config.Rule{Field: "metadata.labels", Op: "HasKey", Value: "team"}},
+		{name: "slice", rule: config.Rule{Field: "spec.ports[*].port", Type: "[]int", Op: "SubsetOf", Value: []interface{}{80, 443}}},
+		{name: "semver range", rule: config.Rule{Field: "spec.version", Type: "semver", Op: "InRange", Value: "^1.2"}},
+		{name: "semver", rule: config.Rule{Field: "spec.version", Type: "semver", Op: "EqualOrGreaterThan", Value: "1.10"}},
+		{name: "unquoted semver", rule: config.Rule{Field: "spec.version", Type: "semver", Op: "EqualOrGreaterThan", Value: 1.1}, errors: true},
+		{name: "unquoted semver in list", rule: config.Rule{Field: "spec.version", Type: "semver", Op: "In", Value: []interface{}{"1.2", 2}}, errors: true},
+		{name: "lookup", rule: config.Rule{Type: "lookup", Lookup: &config.Lookup{ApiVersion: "v1", Kind: "Service", Name: "{{ object.spec.serviceName }}"}}},
+		{name: "lookup with templated kind", rule: config.Rule{Type: "lookup", Lookup: &config.Lookup{
+			ApiVersion: "{{ object.spec.scaleTargetRef.apiVersion }}", Kind: "{{ object.spec.scaleTargetRef.kind }}", Name: "web"}}},
+		{name: "lookup with invalid apiVersion", rule: config.Rule{Type: "lookup", Lookup: &config.Lookup{ApiVersion: "a/b/c", Kind: "Service", Name: "web"}}, errors: true},
+		{name: "lookup with invalid kind template", rule: config.Rule{Type: "lookup", Lookup: &config.Lookup{ApiVersion: "v1", Kind: "{{ spec.kind }}", Name: "web"}}, errors: true},
+		{name: "unknown operator", rule: config.Rule{Field: "spec.type", Type: "string", Op: "Bogus", Value: "a"}, errors: true},
+		{name: "unknown type", rule: config.Rule{Field: "spec.type", Type: "bogus", Op: "Is", Value: "a"}, errors: true},
+		{name: "unknown type with exists", rule: config.Rule{Field: "spec.type", Type: "bogus", Op: "Exists"}, errors: true},
+		{name: "no operator", rule: config.Rule{Field: "spec.type", Type: "string", Value: "a"}, errors: true},
+		{name: "no type", rule: config.Rule{Field: "spec.type", Op: "Is", Value: "a"}, errors: true},
+		{name: "operator not for the type", rule: config.Rule{Field: "spec.hostNetwork", Type: "bool", Op: "In", Value: []interface{}{true}}, errors: true},
+		{name: "ordering for string", rule: config.Rule{Field: "spec.type", Type: "string", Op: "LessThan", Value: "b"}, errors: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			err := CompileRule(&rule)
+			if tt.errors && err == nil {
+				t.Fatalf("CompileRule(%v) did not fail", tt.rule)
+			}
+			if !tt.errors && err != nil {
+				t.Fatalf("CompileRule(%v) failed: %v", tt.rule, err)
+			}
+		})
+	}
+}
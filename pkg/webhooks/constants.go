@@ -6,9 +6,23 @@ const (
 	// for string and equality in genral (==)
 	OperatorIs    Operator = "Is"
 	OperatorIsNot Operator = "IsNot"
-	// for string
+	// for any type, the field path resolves to some value or to none, value is not used
+	OperatorExists    Operator = "Exists"
+	OperatorNotExists Operator = "NotExists"
+	// for string and numeric, value is a list
 	OperatorIn    Operator = "In"
 	OperatorNotIn Operator = "NotIn"
+	// for string, pattern matching, value is a string or a list of strings (matching any)
+	OperatorRegex         Operator = "Regex"
+	OperatorNotRegex      Operator = "NotRegex"
+	OperatorGlob          Operator = "Glob"
+	OperatorNotGlob       Operator = "NotGlob"
+	OperatorStartsWith    Operator = "StartsWith"
+	OperatorNotStartsWith Operator = "NotStartsWith"
+	OperatorEndsWith      Operator = "EndsWith"
+	OperatorNotEndsWith   Operator = "NotEndsWith"
+	OperatorContains      Operator = "Contains"
+	OperatorNotContains   Operator = "NotContains"
 	// for numeric
 	// >
 	OperatorGreaterThan Operator = "GreaterThan"
@@ -22,6 +36,68 @@ const (
 	// <=
 	OperatorEqualOrSmallerThan Operator = "EqualOrSmallerThan"
 	OperatorEqualOrLessThan    Operator = "EqualOrLessThan"
+	// for semver, value is a range (i.e. ^1.2, ~1.4.1 or >=1.4 <2)
+	OperatorInRange    Operator = "InRange"
+	OperatorNotInRange Operator = "NotInRange"
+	// for ip and cidr, value is an IP or a CIDR or a list of them (matching any),
+	// Is and In compare the normalized IP or CIDR
+	// the field is inside the CIDR
+	OperatorInCIDR    Operator = "InCIDR"
+	OperatorNotInCIDR Operator = "NotInCIDR"
+	// the field and the CIDR have some address in common
+	OperatorOverlaps    Operator = "Overlaps"
+	OperatorNotOverlaps Operator = "NotOverlaps"
+	// value is an int compared with the number of characters of a string, of items of a list
+	// or of keys of a map, the rule type is not needed
+	OperatorLengthIs  Operator = "LengthIs"
+	OperatorMinLength Operator = "MinLength"
+	OperatorMaxLength Operator = "MaxLength"
+	// compare the field with its value in the old object (on UPDATE), value is not used,
+	// they do not apply when there is no old object or the field was not set in it
+	OperatorChanged   Operator = "Changed"
+	OperatorUnchanged Operator = "Unchanged"
+	// for numeric and ordered types, the field can only increase (or decrease), an unchanged
+	// value holds so the other updates of the object are not denied
+	OperatorIncreased Operator = "Increased"
+	OperatorDecreased Operator = "Decreased"
+	// for string, value maps the old value to the new values it can change to (* for any)
+	OperatorAllowedTransitions Operator = "AllowedTransitions"
+	// for maps (i.e. metadata.labels), value is a key or a list of keys, the rule type is not needed
+	OperatorHasKey     Operator = "HasKey"
+	OperatorHasAnyKey  Operator = "HasAnyKey"
+	OperatorHasAllKeys Operator = "HasAllKeys"
+	// for maps, value is a regex or a list of them (matching any), all the keys (or values)
+	// have to match, or any or none of them with the quantifier
+	OperatorKeysMatch   Operator = "KeysMatch"
+	OperatorValuesMatch Operator = "ValuesMatch"
+	// for slices, value is a list, Is and IsNot compare them as sets
+	// the field contains all the values
+	OperatorContainsAll Operator = "ContainsAll"
+	// the field contains at least one of the values
+	OperatorContainsAny Operator = "ContainsAny"
+	// the field contains only values from the list
+	OperatorSubsetOf Operator = "SubsetOf"
+	// the field contains none of the values
+	OperatorDisjoint Operator = "Disjoint"
+)
+
+// RuleType is the type of rules that are not verified comparing a field to a value,
+// it is set as the rule type as the value types
+type RuleType = string
+
+const (
+	// the rule expression is a CEL expression evaluating to a bool
+	RuleTypeCEL RuleType = "cel"
+	// the rule gets an object from the cluster (see config.Lookup) and verifies nested rules on it
+	RuleTypeLookup RuleType = "lookup"
+	// the values of the field can't be the same of the ones of any other object of the kind,
+	// they are looked up in a cache index only for the rule sets with an explicit apiVersion
+	// and kind at startup (see SetupUniqueIndexes)
+	RuleTypeUnique RuleType = "unique"
+	// the objects of the kind can't be more than a limit (see config.CountLimit)
+	RuleTypeCountLimit RuleType = "countLimit"
+	// the values of the field (the whole object without a field) are validated against a JSON Schema
+	RuleTypeJSONSchema RuleType = "jsonSchema"
 )
 
 type ValueType = string
@@ -33,11 +109,76 @@ const (
 	ValueTypeInt64   ValueType = "int64"
 	ValueTypeFloat   ValueType = "float"
 	ValueTypeFloat64 ValueType = "float64"
+	// a resource.Quantity (i.e. 512Mi or 0.5), works with the numeric operators
+	ValueTypeQuantity ValueType = "quantity"
+	// a duration (i.e. 1h30m, 30d or 2w) or a number of seconds, works with the numeric operators
+	ValueTypeDuration ValueType = "duration"
+	// a RFC3339 time, in rules it can be relative to the admission time (now, now+30d, now-1h),
+	// works with the numeric operators (LessThan is before, GreaterThan is after)
+	ValueTypeTimestamp ValueType = "timestamp"
+	// a semantic version, also the tag of an image reference, works with the numeric
+	// operators and the range ones, in rules it has to be quoted (1.10 is decoded as 1.1)
+	ValueTypeSemver ValueType = "semver"
+	// a container image reference, normalized (nginx is docker.io/library/nginx:latest)
+	// and verified as a string, or just a component of it
+	ValueTypeImage ValueType = "image"
+	// an IP address or a CIDR (a bare IP is the CIDR of just that address), the field can be
+	// a list of them (i.e. spec.externalIPs) and the rule is verified on each one
+	ValueTypeIP   ValueType = "ip"
+	ValueTypeCIDR ValueType = "cidr"
+	// an int, a percentage (i.e. 25%) or a name (i.e. a named port), ints and percentages
+	// are compared as numbers and names as strings
+	ValueTypeIntOrString ValueType = "intOrString"
+	// any value (i.e. a map), Is and IsNot compare it as a whole
+	ValueTypeObject ValueType = "object"
+
+	// the field is a list or a field path resolving to many values (i.e. spec.ports[*].port)
+	ValueTypeStringSlice  ValueType = "[]string"
+	ValueTypeBoolSlice    ValueType = "[]bool"
+	ValueTypeIntSlice     ValueType = "[]int"
+	ValueTypeInt64Slice   ValueType = "[]int64"
+	ValueTypeFloatSlice   ValueType = "[]float"
+	ValueTypeFloat64Slice ValueType = "[]float64"
+)
+
+// Quantifier tells how the results for the values of a field path with wildcards or filters are combined
+type Quantifier = string
+
+const (
+	// default, the rule has to be verified by all the values
+	QuantifierAll  Quantifier = "all"
+	QuantifierAny  Quantifier = "any"
+	QuantifierNone Quantifier = "none"
+)
 
-	// ValueTypeStringSlice  ValueType = "[]string"
-	// ValueTypeBoolSlice    ValueType = "[]bool"
-	// ValueTypeIntSlice     ValueType = "[]int"
-	// ValueTypeInt64Slice   ValueType = "[]int64"
-	// ValueTypeFloatSlice   ValueType = "[]float"
-	// ValueTypeFloat64Slice ValueType = "[]float64"
+// MissingPolicy tells how a rule is verified when its field is missing
+type MissingPolicy = string
+
+const (
+	// default, the rule is violated
+	OnMissingDeny MissingPolicy = "deny"
+	// the rule is verified
+	OnMissingAllow MissingPolicy = "allow"
+	// the rule is verified against its default value
+	OnMissingDefault MissingPolicy = "default"
+)
+
+// Scope is where a rule of type unique or countLimit looks for the other objects
+type Scope = string
+
+const (
+	// all the objects of the kind, default for unique
+	ScopeCluster Scope = "cluster"
+	// the objects of the kind in the namespace of the request, default for countLimit
+	ScopeNamespace Scope = "namespace"
+)
+
+// ImageComponent is the part of an image reference a rule of type image is about
+type ImageComponent = string
+
+const (
+	ImageComponentRegistry   ImageComponent = "registry"
+	ImageComponentRepository ImageComponent = "repository"
+	ImageComponentTag        ImageComponent = "tag"
+	ImageComponentDigest     ImageComponent = "digest"
 )
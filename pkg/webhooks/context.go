@@ -0,0 +1,80 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ruleContext is what the rules are verified against for an admission request,
+// what needs a lookup or a conversion is got once and just if some rule needs it
+type ruleContext struct {
+	ctx    context.Context
+	client client.Client
+	req    admission.Request
+	// cache is the manager one, it can read any kind (unstructured) for the lookups
+	cache client.Reader
+	// object is the one in the request, the existing one on DELETE
+	object map[string]interface{}
+	// oldObject is nil on CREATE, on DELETE it is the existing one as object
+	oldObject map[string]interface{}
+
+	namespace        *corev1.Namespace
+	namespaceFetched bool
+	request          map[string]interface{}
+}
+
+// getNamespace returns the namespace of the request from the manager cache, nil for cluster scoped objects
+func (rc *ruleContext) getNamespace() (*corev1.Namespace, error) {
+	if rc.namespaceFetched || rc.req.Namespace == "" {
+		return rc.namespace, nil
+	}
+	ns := &corev1.Namespace{}
+	if err := rc.client.Get(rc.ctx, types.NamespacedName{Name: rc.req.Namespace}, ns); err != nil {
+		return nil, fmt.Errorf("could not get namespace %s: %v", rc.req.Namespace, err)
+	}
+	rc.namespace, rc.namespaceFetched = ns, true
+	return ns, nil
+}
+
+func (rc *ruleContext) namespaceLabels() (map[string]string, error) {
+	ns, err := rc.getNamespace()
+	if err != nil || ns == nil {
+		return nil, err
+	}
+	return ns.GetLabels(), nil
+}
+
+// namespaceObject returns the namespace of the request as an unstructured object
+func (rc *ruleContext) namespaceObject() (map[string]interface{}, error) {
+	ns, err := rc.getNamespace()
+	if err != nil || ns == nil {
+		return nil, err
+	}
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(ns)
+}
+
+// requestObject returns the admission request, without the objects, as an unstructured object
+func (rc *ruleContext) requestObject() (map[string]interface{}, error) {
+	if rc.request != nil {
+		return rc.request, nil
+	}
+	req := rc.req.AdmissionRequest.DeepCopy()
+	req.Object, req.OldObject, req.Options = runtime.RawExtension{}, runtime.RawExtension{}, runtime.RawExtension{}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	request := map[string]interface{}{}
+	if err := json.Unmarshal(data, &request); err != nil {
+		return nil, err
+	}
+	rc.request = request
+	return request, nil
+}
@@ -0,0 +1,146 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+)
+
+type compiledCountLimit struct {
+	selector labels.Selector
+}
+
+func compileCountLimit(rule *config.Rule) (*compiledCountLimit, error) {
+	countLimit := rule.CountLimit
+	if countLimit == nil {
+		return nil, fmt.Errorf("a rule of type %s needs a countLimit", RuleTypeCountLimit)
+	}
+	if countLimit.Limit < 0 {
+		return nil, fmt.Errorf("countLimit limit %d is not valid", countLimit.Limit)
+	}
+	switch rule.Scope {
+	case "", ScopeCluster, ScopeNamespace:
+	default:
+		return nil, fmt.Errorf("unknown scope: %s", rule.Scope)
+	}
+	compiled := &compiledCountLimit{selector: labels.Everything()}
+	if countLimit.LabelSelector != nil {
+		selector, err := countLimit.LabelSelector.ToSelector()
+		if err != nil {
+			return nil, fmt.Errorf("countLimit labelSelector: %v", err)
+		}
+		compiled.selector = selector
+	}
+	return compiled, nil
+}
+
+// isCounted tells if an object is selected by the label selector and the rules of the countLimit,
+// an object missing a field of the rules is not counted
+func (v *genericValidator) isCounted(rc *ruleContext, rule config.Rule, compiled *compiledCountLimit, obj map[string]interface{}) (bool, *violation) {
+	u := &unstructured.Unstructured{Object: obj}
+	if !compiled.selector.Matches(labels.Set(u.GetLabels())) {
+		return false, nil
+	}
+	counted := &ruleContext{ctx: rc.ctx, client: rc.client, cache: rc.cache, req: rc.req, object: obj}
+	for i, sub := range rule.CountLimit.Rules {
+		if vi := v.evaluate(counted, sub); vi.denies() {
+			if vi.err != nil && !isMissingValue(vi.err) {
+				return false, vi.within(fmt.Sprintf("countLimit.rules[%d]", i))
+			}
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// getLimit returns the limit of the rule, or the one in the namespace annotation
+func getLimit(rc *ruleContext, rule config.Rule) (int, error) {
+	if rule.CountLimit.Annotation == "" {
+		return rule.CountLimit.Limit, nil
+	}
+	ns, err := rc.getNamespace()
+	if err != nil || ns == nil {
+		return rule.CountLimit.Limit, err
+	}
+	value, found := ns.GetAnnotations()[rule.CountLimit.Annotation]
+	if !found {
+		return rule.CountLimit.Limit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("annotation %s of namespace %s is not a valid limit: %q",
+			rule.CountLimit.Annotation, ns.Name, value)
+	}
+	return limit, nil
+}
+
+// evaluateCountLimit counts the objects of the kind in the cache, the one in the request is
+// not counted twice on UPDATE, and it is violated if with the one in the request they are
+// more than the limit, removing an object is always fine and so is updating one that was
+// already counted, only the objects that become counted are denied
+func (v *genericValidator) evaluateCountLimit(rc *ruleContext, rule config.Rule) *violation {
+	if rc.req.Operation == admissionv1beta1.Delete {
+		return nil
+	}
+	compiled, err := getCompiled(rule)
+	if err != nil {
+		return &violation{rule: rule, err: err}
+	}
+	if counted, vi := v.isCounted(rc, rule, compiled.countLimit, rc.object); vi != nil || !counted {
+		return vi
+	}
+	if rc.oldObject != nil {
+		if counted, vi := v.isCounted(rc, rule, compiled.countLimit, rc.oldObject); vi != nil || counted {
+			return vi
+		}
+	}
+	limit, err := getLimit(rc, rule)
+	if err != nil {
+		return &violation{rule: rule, err: err}
+	}
+
+	gvk := schema.GroupVersionKind(rc.req.Kind)
+	self := &unstructured.Unstructured{Object: rc.object}
+	name := self.GetName()
+	if name == "" {
+		name = rc.req.Name
+	}
+	opts := []client.ListOption{client.MatchingLabelsSelector{Selector: compiled.countLimit.selector}}
+	where := "in the cluster"
+	if rule.Scope != ScopeCluster {
+		opts = append(opts, client.InNamespace(rc.req.Namespace))
+		where = "in namespace " + rc.req.Namespace
+	}
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	ctx, cancel := context.WithTimeout(rc.ctx, defaultLookupTimeout)
+	defer cancel()
+	if err := rc.cache.List(ctx, list, opts...); err != nil {
+		return &violation{rule: rule, err: fmt.Errorf("could not list %s: %v", gvk.Kind, err)}
+	}
+	count := 1
+	for _, item := range list.Items {
+		if item.GetNamespace() == rc.req.Namespace && item.GetName() == name {
+			continue
+		}
+		counted, vi := v.isCounted(rc, rule, compiled.countLimit, item.Object)
+		if vi != nil {
+			return vi
+		}
+		if counted {
+			count++
+		}
+	}
+	if count > limit {
+		return &violation{rule: rule, err: fmt.Errorf("the limit of %d %s objects %s is reached", limit, gvk.Kind, where)}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+)
+
+// violation is the branch of a rule that was not verified
+type violation struct {
+	// branch is the path of the failing rule from the evaluated one, empty if it is the same
+	branch string
+	rule   config.Rule
+	err    error
+	// causes are the violations of the branches of an anyOf
+	causes []*violation
+	// reasons detail why the rule is violated (i.e. the JSON Schema errors)
+	reasons []string
+	// notApplicable is set when the rule can't be verified (i.e. it is about the old object on
+	// CREATE), it is neutral: it is not denied, a not does not flip it, a when on it is not met
+	// and an anyOf does not pass on it
+	notApplicable bool
+}
+
+// errNotApplicable is returned verifying a rule that does not apply to the request
+var errNotApplicable = errors.New("rule not applicable")
+
+func notApplicable(rule config.Rule) *violation {
+	return &violation{rule: rule, notApplicable: true}
+}
+
+// denies is true for a violation that is not neutral
+func (vi *violation) denies() bool {
+	return vi != nil && !vi.notApplicable
+}
+
+func (vi *violation) String() string {
+	if vi.err != nil {
+		return fmt.Sprintf("%s (error: %v)", vi.where(), vi.err)
+	}
+	if len(vi.reasons) > 0 {
+		return fmt.Sprintf("%s (%s)", vi.where(), strings.Join(vi.reasons, "; "))
+	}
+	return vi.where()
+}
+
+// where describes the violated branch and rule, for an anyOf the violations of its branches
+func (vi *violation) where() string {
+	if len(vi.causes) > 0 {
+		causes := []string{}
+		for _, cause := range vi.causes {
+			causes = append(causes, cause.String())
+		}
+		return fmt.Sprintf("%s: none verified [%s]", vi.branch, strings.Join(causes, "; "))
+	}
+	if vi.branch != "" {
+		return fmt.Sprintf("%s: %v", vi.branch, vi.rule)
+	}
+	return fmt.Sprintf("%v", vi.rule)
+}
+
+func joinBranch(parent, child string) string {
+	if child == "" {
+		return parent
+	}
+	return parent + "." + child
+}
+
+// within returns the violation as seen from the parent rule
+func (vi *violation) within(branch string) *violation {
+	vi.branch = joinBranch(branch, vi.branch)
+	for _, cause := range vi.causes {
+		cause.within(branch)
+	}
+	return vi
+}
+
+// evaluate verifies a rule, that can be a group of rules or a conditional one,
+// it returns nil when the rule is verified
+func (v *genericValidator) evaluate(rc *ruleContext, rule config.Rule) *violation {
+	if rule.When != nil {
+		cond := v.evaluate(rc, *rule.When)
+		// a missing field is a condition not met, not an error
+		if cond != nil && cond.err != nil && !isMissingValue(cond.err) {
+			return cond.within("when")
+		}
+		if cond != nil {
+			// condition not met, nothing to verify
+			return nil
+		}
+	}
+
+	switch {
+	case len(rule.AllOf) > 0:
+		// it does not apply only if none of the rules does
+		applies := false
+		for i, sub := range rule.AllOf {
+			vi := v.evaluate(rc, sub)
+			if vi.denies() {
+				return vi.within(fmt.Sprintf("allOf[%d]", i))
+			}
+			applies = applies || vi == nil
+		}
+		if !applies {
+			return notApplicable(rule)
+		}
+		return nil
+	case len(rule.AnyOf) > 0:
+		causes := []*violation{}
+		for i, sub := range rule.AnyOf {
+			vi := v.evaluate(rc, sub)
+			if vi == nil {
+				return nil
+			}
+			if vi.denies() {
+				causes = append(causes, vi.within(fmt.Sprintf("anyOf[%d]", i)))
+			}
+		}
+		if len(causes) == 0 {
+			return notApplicable(rule)
+		}
+		return &violation{branch: "anyOf", rule: rule, causes: causes}
+	case rule.Type == RuleTypeLookup:
+		return v.evaluateLookup(rc, rule)
+	case rule.Type == RuleTypeUnique:
+		return v.evaluateUnique(rc, rule)
+	case rule.Type == RuleTypeCountLimit:
+		return v.evaluateCountLimit(rc, rule)
+	case rule.Type == RuleTypeJSONSchema:
+		return v.evaluateJSONSchema(rc, rule)
+	case rule.Not != nil:
+		vi := v.evaluate(rc, *rule.Not)
+		if vi == nil {
+			return &violation{branch: "not", rule: *rule.Not}
+		}
+		// a missing field is a rule not satisfied, so the not holds
+		if vi.notApplicable || (vi.err != nil && !isMissingValue(vi.err)) {
+			return vi.within("not")
+		}
+		return nil
+	}
+
+	var ok bool
+	var err error
+	switch rule.Type {
+	case RuleTypeCEL:
+		ok, err = v.verifyCEL(rc, rule)
+	default:
+		ok, err = v.verify(rc, rule)
+	}
+	if err == errNotApplicable {
+		return notApplicable(rule)
+	}
+	if !ok || err != nil {
+		return &violation{rule: rule, err: err}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+)
+
+func service(serviceType, team string) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":   "web",
+			"labels": map[string]interface{}{"team": team},
+		},
+		"spec": map[string]interface{}{"type": serviceType},
+	}
+}
+
+// evaluateCase compiles the rule and tells if it denies the object, as the validator does
+type evaluateCase struct {
+	name      string
+	rule      config.Rule
+	object    map[string]interface{}
+	oldObject map[string]interface{}
+	denied    bool
+}
+
+func runEvaluateCases(t *testing.T, cases []evaluateCase) {
+	v := &genericValidator{log: logf.NullLogger{}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			if err := CompileRule(&rule); err != nil {
+				t.Fatalf("CompileRule(%v) failed: %v", rule, err)
+			}
+			rc := &ruleContext{ctx: context.Background(), object: tt.object, oldObject: tt.oldObject}
+			vi := v.evaluate(rc, rule)
+			if vi.denies() != tt.denied {
+				t.Errorf("evaluate(%v) = %v, denied %v, want %v", rule, vi, vi.denies(), tt.denied)
+			}
+		})
+	}
+}
+
+func TestEvaluateOldObject(t *testing.T) {
+	oldIsLoadBalancer := config.Rule{Field: "old.spec.type", Type: "string", Op: "Is", Value: "LoadBalancer"}
+	teamChanged := config.Rule{Field: "metadata.labels.team", Type: "string", Op: "Changed"}
+	isClusterIP := config.Rule{Field: "spec.type", Type: "string", Op: "Is", Value: "ClusterIP"}
+
+	runEvaluateCases(t, []evaluateCase{
+		{name: "old field on create", rule: oldIsLoadBalancer, object: service("ClusterIP", "a")},
+		{name: "old field on update", rule: oldIsLoadBalancer,
+			object: service("ClusterIP", "a"), oldObject: service("ClusterIP", "a"), denied: true},
+		{name: "not old field on create",
+			rule:   config.Rule{Not: &oldIsLoadBalancer},
+			object: service("ClusterIP", "a")},
+		{name: "not old field on update",
+			rule:   config.Rule{Not: &oldIsLoadBalancer},
+			object: service("ClusterIP", "a"), oldObject: service("LoadBalancer", "a"), denied: true},
+		{name: "not changed on create",
+			rule:   config.Rule{Not: &teamChanged},
+			object: service("ClusterIP", "a")},
+		{name: "not changed on update",
+			rule:   config.Rule{Not: &teamChanged},
+			object: service("ClusterIP", "b"), oldObject: service("ClusterIP", "a"), denied: true},
+		{name: "when on old field on create",
+			rule:   config.Rule{Field: "spec.type", Type: "string", Op: "Is", Value: "LoadBalancer", When: &oldIsLoadBalancer},
+			object: service("ClusterIP", "a")},
+		{name: "when on old field on update",
+			rule:   config.Rule{Field: "spec.type", Type: "string", Op: "Is", Value: "LoadBalancer", When: &oldIsLoadBalancer},
+			object: service("ClusterIP", "a"), oldObject: service("LoadBalancer", "a"), denied: true},
+		{name: "anyOf does not pass on old field on create",
+			rule:   config.Rule{AnyOf: []config.Rule{oldIsLoadBalancer, isClusterIP}},
+			object: service("NodePort", "a"), denied: true},
+		{name: "anyOf of old fields on create",
+			rule:   config.Rule{AnyOf: []config.Rule{oldIsLoadBalancer, teamChanged}},
+			object: service("NodePort", "a")},
+		{name: "allOf with old field on create",
+			rule:   config.Rule{AllOf: []config.Rule{oldIsLoadBalancer, isClusterIP}},
+			object: service("NodePort", "a"), denied: true},
+		{name: "not allOf of old fields on create",
+			rule:   config.Rule{Not: &config.Rule{AllOf: []config.Rule{oldIsLoadBalancer, teamChanged}}},
+			object: service("NodePort", "a")},
+		{name: "valueFrom old field on create",
+			rule:   config.Rule{Field: "spec.type", Type: "string", Op: "Is", ValueFrom: &config.ValueFrom{Field: "old.spec.type"}},
+			object: service("NodePort", "a")},
+		{name: "template of old object on update",
+			rule:   config.Rule{Field: "spec.type", Type: "string", Op: "Is", Value: "{{ oldObject.spec.type }}"},
+			object: service("NodePort", "a"), oldObject: service("ClusterIP", "a"), denied: true},
+	})
+}
+
+func deployment(replicas int64) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web"},
+		"spec":     map[string]interface{}{"replicas": replicas},
+	}
+}
+
+func TestEvaluateIncreasedDecreased(t *testing.T) {
+	increased := config.Rule{Field: "spec.replicas", Type: "int", Op: "Increased"}
+	decreased := config.Rule{Field: "spec.replicas", Type: "int", Op: "Decreased"}
+
+	runEvaluateCases(t, []evaluateCase{
+		{name: "increased", rule: increased, object: deployment(3), oldObject: deployment(2)},
+		{name: "increased unchanged", rule: increased, object: deployment(2), oldObject: deployment(2)},
+		{name: "increased decreasing", rule: increased, object: deployment(1), oldObject: deployment(2), denied: true},
+		{name: "decreased", rule: decreased, object: deployment(1), oldObject: deployment(2)},
+		{name: "decreased unchanged", rule: decreased, object: deployment(2), oldObject: deployment(2)},
+		{name: "decreased increasing", rule: decreased, object: deployment(3), oldObject: deployment(2), denied: true},
+		{name: "decreased on create", rule: decreased, object: deployment(3)},
+	})
+}
+
+func pod(spec map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web"},
+		"spec":     spec,
+	}
+}
+
+func TestEvaluateMissingField(t *testing.T) {
+	hostNetwork := config.Rule{Field: "spec.hostNetwork", Type: "bool", Op: "Is", Value: true}
+
+	runEvaluateCases(t, []evaluateCase{
+		{name: "missing field", rule: hostNetwork, object: pod(map[string]interface{}{}), denied: true},
+		{name: "not on a missing field", rule: config.Rule{Not: &hostNetwork}, object: pod(map[string]interface{}{})},
+		{name: "not on a set field", rule: config.Rule{Not: &hostNetwork},
+			object: pod(map[string]interface{}{"hostNetwork": true}), denied: true},
+		{name: "when on a missing field",
+			rule:   config.Rule{Field: "spec.dnsPolicy", Type: "string", Op: "Is", Value: "ClusterFirstWithHostNet", When: &hostNetwork},
+			object: pod(map[string]interface{}{})},
+		{name: "when on a set field",
+			rule:   config.Rule{Field: "spec.dnsPolicy", Type: "string", Op: "Is", Value: "ClusterFirstWithHostNet", When: &hostNetwork},
+			object: pod(map[string]interface{}{"hostNetwork": true}), denied: true},
+	})
+}
+
+func TestEvaluateSemver(t *testing.T) {
+	atLeast110 := config.Rule{Field: "spec.version", Type: "semver", Op: "EqualOrGreaterThan", Value: "1.10"}
+
+	runEvaluateCases(t, []evaluateCase{
+		{name: "lower version", rule: atLeast110, object: pod(map[string]interface{}{"version": "1.2.0"}), denied: true},
+		{name: "same version", rule: atLeast110, object: pod(map[string]interface{}{"version": "1.10.0"})},
+		{name: "int version", rule: atLeast110, object: pod(map[string]interface{}{"version": int64(2)})},
+		{name: "float version", rule: atLeast110, object: pod(map[string]interface{}{"version": 1.1}), denied: true},
+	})
+}
+
+func TestEvaluateTimestamp(t *testing.T) {
+	within30d := config.Rule{Field: "metadata.annotations.expires-at", Type: "timestamp", Op: "LessThan", Value: "now+30d"}
+	annotated := func(expiresAt string) map[string]interface{} {
+		return map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":        "web",
+				"annotations": map[string]interface{}{"expires-at": expiresAt},
+			},
+		}
+	}
+
+	runEvaluateCases(t, []evaluateCase{
+		{name: "before", rule: within30d, object: annotated(time.Now().Add(24 * time.Hour).Format(time.RFC3339))},
+		{name: "after", rule: within30d, object: annotated(time.Now().Add(60 * 24 * time.Hour).Format(time.RFC3339)), denied: true},
+		{name: "relative in the field", rule: within30d, object: annotated("now"), denied: true},
+		{name: "relative offset in the field", rule: within30d, object: annotated("now+1h"), denied: true},
+	})
+}
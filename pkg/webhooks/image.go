@@ -0,0 +1,150 @@
+package webhooks
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+)
+
+const (
+	defaultImageRegistry = "docker.io"
+	defaultImageTag      = "latest"
+)
+
+var (
+	imageRepositoryRe = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	imageTagRe        = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	imageDigestRe     = regexp.MustCompile(`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-fA-F0-9]{32,}$`)
+)
+
+// imageReference is a normalized container image reference, as the container runtimes
+// resolve it: nginx is docker.io/library/nginx:latest
+type imageReference struct {
+	registry   string
+	repository string
+	tag        string
+	digest     string
+}
+
+func (r imageReference) String() string {
+	s := r.registry + "/" + r.repository
+	if r.tag != "" {
+		s += ":" + r.tag
+	}
+	if r.digest != "" {
+		s += "@" + r.digest
+	}
+	return s
+}
+
+// component returns the component of the reference, the whole normalized one if it is empty
+func (r imageReference) component(name string) (string, error) {
+	switch name {
+	case "":
+		return r.String(), nil
+	case ImageComponentRegistry:
+		return r.registry, nil
+	case ImageComponentRepository:
+		return r.repository, nil
+	case ImageComponentTag:
+		return r.tag, nil
+	case ImageComponentDigest:
+		return r.digest, nil
+	}
+	return "", fmt.Errorf("unknown image component: %s", name)
+}
+
+// parseImageReference parses and normalizes [registry/]repository[:tag][@digest]
+func parseImageReference(s string) (imageReference, error) {
+	ref := imageReference{}
+	name := s
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.digest = name[:i], name[i+1:]
+		if !imageDigestRe.MatchString(ref.digest) {
+			return ref, fmt.Errorf("invalid digest in image reference %q", s)
+		}
+	}
+	if i := strings.LastIndex(name, ":"); i >= 0 && !strings.Contains(name[i:], "/") {
+		name, ref.tag = name[:i], name[i+1:]
+		if !imageTagRe.MatchString(ref.tag) {
+			return ref, fmt.Errorf("invalid tag in image reference %q", s)
+		}
+	}
+	ref.registry, ref.repository = defaultImageRegistry, name
+	if i := strings.Index(name, "/"); i >= 0 {
+		first := name[:i]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			ref.registry, ref.repository = strings.ToLower(first), name[i+1:]
+		}
+	}
+	if ref.registry == "index.docker.io" {
+		ref.registry = defaultImageRegistry
+	}
+	if ref.registry == defaultImageRegistry && !strings.Contains(ref.repository, "/") {
+		ref.repository = "library/" + ref.repository
+	}
+	if !imageRepositoryRe.MatchString(ref.repository) {
+		return ref, fmt.Errorf("invalid repository in image reference %q", s)
+	}
+	if ref.tag == "" && ref.digest == "" {
+		ref.tag = defaultImageTag
+	}
+	return ref, nil
+}
+
+// compileImage normalizes the rule values when the rule is about the whole reference
+func compileImage(rule *config.Rule) (interface{}, error) {
+	if _, err := (imageReference{}).component(rule.Component); err != nil {
+		return nil, err
+	}
+	if rule.Component != "" || rule.Op == OperatorExists || rule.Op == OperatorNotExists {
+		return rule.Value, nil
+	}
+	if _, isPatternOp := patternOperators[rule.Op]; isPatternOp {
+		return rule.Value, nil
+	}
+	values, ok := toStringSlice(rule.Value)
+	if !ok {
+		return nil, fmt.Errorf("Value (of type %T) in rule is not a string nor a list of strings", rule.Value)
+	}
+	normalized := []interface{}{}
+	for _, value := range values {
+		ref, err := parseImageReference(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in rule: %v", err)
+		}
+		normalized = append(normalized, ref.String())
+	}
+	if _, isString := rule.Value.(string); isString {
+		return normalized[0], nil
+	}
+	return normalized, nil
+}
+
+// verifyImage verifies a component of an image reference (or the whole normalized one)
+// as a string, Exists and NotExists are about the component being set (i.e. the digest)
+func verifyImage(value interface{}, rule config.Rule, compiled *compiledRule) (bool, error) {
+	s, ok := value.(string)
+	if !ok {
+		return false, fmt.Errorf("%v accessor error: %v is of the type %T, expected string",
+			rule.Field, value, value)
+	}
+	ref, err := parseImageReference(s)
+	if err != nil {
+		return false, &invalidValueError{field: rule.Field, err: err}
+	}
+	component, err := ref.component(rule.Component)
+	if err != nil {
+		return false, err
+	}
+	switch rule.Op {
+	case OperatorExists:
+		return component != "", nil
+	case OperatorNotExists:
+		return component == "", nil
+	}
+	rule.Value = compiled.imageValue
+	return verifyString(component, rule, compiled)
+}
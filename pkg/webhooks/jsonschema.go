@@ -0,0 +1,72 @@
+package webhooks
+
+import (
+	"fmt"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+)
+
+// compileJSONSchema compiles the schema of the rule, inline or referenced from the configuration
+func compileJSONSchema(rule *config.Rule) (*gojsonschema.Schema, error) {
+	if rule.Schema == nil {
+		return nil, fmt.Errorf("a rule of type %s needs a schema or a schemaRef", RuleTypeJSONSchema)
+	}
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewGoLoader(normalizeYamlValue(rule.Schema)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	return schema, nil
+}
+
+// evaluateJSONSchema validates the values of the field (the whole object without a field) against
+// the schema, the violation has all the errors with their paths
+func (v *genericValidator) evaluateJSONSchema(rc *ruleContext, rule config.Rule) *violation {
+	compiled, err := getCompiled(rule)
+	if err != nil {
+		return &violation{rule: rule, err: err}
+	}
+	obj := rc.object
+	if compiled.old {
+		if rc.oldObject == nil {
+			return notApplicable(rule)
+		}
+		obj = rc.oldObject
+	}
+	values := compiled.path.Resolve(obj)
+	if len(values) == 0 {
+		if rule.OnMissing == OnMissingAllow {
+			return nil
+		}
+		return &violation{rule: rule, err: &missingValueError{field: rule.Field}}
+	}
+	reasons := []string{}
+	for _, value := range values {
+		result, err := compiled.schema.Validate(gojsonschema.NewGoLoader(value))
+		if err != nil {
+			return &violation{rule: rule, err: err}
+		}
+		for _, resultErr := range result.Errors() {
+			reasons = append(reasons, fmt.Sprintf("%s: %s",
+				joinFieldPath(rule.Field, resultErr.Field()), resultErr.Description()))
+		}
+	}
+	if len(reasons) > 0 {
+		return &violation{rule: rule, reasons: reasons}
+	}
+	return nil
+}
+
+// joinFieldPath joins the path of a field with the one of a schema error inside it
+func joinFieldPath(field, errField string) string {
+	switch {
+	case errField == gojsonschema.STRING_CONTEXT_ROOT && field == "":
+		return "."
+	case errField == gojsonschema.STRING_CONTEXT_ROOT:
+		return field
+	case field == "":
+		return errField
+	}
+	return field + "." + errField
+}
@@ -0,0 +1,56 @@
+package webhooks
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+)
+
+// lengthOperators compare the length of the field with the rule value
+var lengthOperators = map[Operator]func(length, limit int64) bool{
+	OperatorLengthIs:  func(length, limit int64) bool { return length == limit },
+	OperatorMinLength: func(length, limit int64) bool { return length >= limit },
+	OperatorMaxLength: func(length, limit int64) bool { return length <= limit },
+}
+
+// compileLength returns the rule value of a length operator
+func compileLength(rule *config.Rule) (int64, error) {
+	var limit int64
+	switch v := rule.Value.(type) {
+	case int:
+		limit = int64(v)
+	case int64:
+		limit = v
+	default:
+		return 0, fmt.Errorf("Value (of type %T) in rule is not an int", rule.Value)
+	}
+	if limit < 0 {
+		return 0, fmt.Errorf("Value %d in rule is not a valid length", limit)
+	}
+	return limit, nil
+}
+
+// lengthOf is the number of characters of a string, of items of a list or of keys of a map
+func lengthOf(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case string:
+		return int64(utf8.RuneCountInString(v)), true
+	case []interface{}:
+		return int64(len(v)), true
+	case map[string]interface{}:
+		return int64(len(v)), true
+	}
+	return 0, false
+}
+
+func verifyLength(value interface{}, rule config.Rule, limit int64) (bool, error) {
+	length, ok := lengthOf(value)
+	if !ok {
+		return false, &invalidValueError{
+			field: rule.Field,
+			err:   fmt.Errorf("%v is of the type %T, expected a string, a list or a map", value, value),
+		}
+	}
+	return lengthOperators[rule.Op](length, limit), nil
+}
@@ -0,0 +1,152 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+)
+
+const defaultLookupTimeout = 2 * time.Second
+
+type compiledLookup struct {
+	apiVersion *valueTemplate
+	kind       *valueTemplate
+	name       *valueTemplate
+	namespace  *valueTemplate
+	timeout    time.Duration
+}
+
+func compileLookup(rule *config.Rule) (*compiledLookup, error) {
+	lookup := rule.Lookup
+	if lookup == nil {
+		return nil, fmt.Errorf("a rule of type %s needs a lookup", RuleTypeLookup)
+	}
+	// without templates the apiVersion is verified once
+	if !isTemplate(lookup.ApiVersion) {
+		if _, err := schema.ParseGroupVersion(lookup.ApiVersion); err != nil {
+			return nil, fmt.Errorf("lookup: %v", err)
+		}
+	}
+	if lookup.Kind == "" || lookup.Name == "" {
+		return nil, fmt.Errorf("lookup needs kind and name")
+	}
+	for _, policy := range []MissingPolicy{lookup.OnNotFound, lookup.OnUnavailable} {
+		switch policy {
+		case "", OnMissingDeny, OnMissingAllow:
+		default:
+			return nil, fmt.Errorf("unknown lookup onNotFound/onUnavailable policy: %s", policy)
+		}
+	}
+	var err error
+	compiled := &compiledLookup{timeout: defaultLookupTimeout}
+	if compiled.apiVersion, err = compileTemplate(lookup.ApiVersion); err != nil {
+		return nil, fmt.Errorf("lookup apiVersion: %v", err)
+	}
+	if compiled.kind, err = compileTemplate(lookup.Kind); err != nil {
+		return nil, fmt.Errorf("lookup kind: %v", err)
+	}
+	if compiled.name, err = compileTemplate(lookup.Name); err != nil {
+		return nil, fmt.Errorf("lookup name: %v", err)
+	}
+	if compiled.namespace, err = compileTemplate(lookup.Namespace); err != nil {
+		return nil, fmt.Errorf("lookup namespace: %v", err)
+	}
+	if lookup.Timeout != "" {
+		if compiled.timeout, err = time.ParseDuration(lookup.Timeout); err != nil {
+			return nil, fmt.Errorf("lookup timeout: %v", err)
+		}
+	}
+	return compiled, nil
+}
+
+// groupVersionKind renders the apiVersion and kind of the referenced object
+func (l *compiledLookup) groupVersionKind(rc *ruleContext) (schema.GroupVersionKind, error) {
+	apiVersion, err := l.apiVersion.render(rc)
+	if err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+	kind, err := l.kind.render(rc)
+	if err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return schema.GroupVersionKind{}, fmt.Errorf("lookup: %v", err)
+	}
+	if kind == "" {
+		return schema.GroupVersionKind{}, fmt.Errorf("lookup kind %q is empty", l.kind.raw)
+	}
+	return gv.WithKind(kind), nil
+}
+
+// isUnavailable is true for the errors of a cache that can't answer yet
+func isUnavailable(err error) bool {
+	if _, notStarted := err.(*cache.ErrCacheNotStarted); notStarted {
+		return true
+	}
+	return apierrors.IsTimeout(err) || err == context.DeadlineExceeded
+}
+
+// evaluateLookup gets the referenced object through the cache and evaluates the nested rules on it
+func (v *genericValidator) evaluateLookup(rc *ruleContext, rule config.Rule) *violation {
+	compiled, err := getCompiled(rule)
+	if err != nil {
+		return &violation{rule: rule, err: err}
+	}
+	lookup := compiled.lookup
+	key := types.NamespacedName{}
+	gvk, err := lookup.groupVersionKind(rc)
+	if err == nil {
+		key.Name, err = lookup.name.render(rc)
+	}
+	if err == nil && !rule.Lookup.ClusterScoped {
+		key.Namespace, err = lookup.namespace.render(rc)
+		if key.Namespace == "" {
+			key.Namespace = rc.req.Namespace
+		}
+	}
+	if err != nil {
+		if isMissingValue(err) && rule.OnMissing == OnMissingAllow {
+			return nil
+		}
+		return &violation{rule: rule, err: err}
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	ctx, cancel := context.WithTimeout(rc.ctx, lookup.timeout)
+	defer cancel()
+	err = rc.cache.Get(ctx, key, obj)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		if rule.Lookup.OnNotFound == OnMissingAllow {
+			return nil
+		}
+		return &violation{rule: rule, err: fmt.Errorf("%s %s not found", gvk.Kind, key)}
+	case isUnavailable(err):
+		v.log.Info("lookup unavailable", "kind", gvk, "key", key, "error", err)
+		if rule.Lookup.OnUnavailable == OnMissingAllow {
+			return nil
+		}
+		return &violation{rule: rule, err: fmt.Errorf("could not get %s %s: %v", gvk.Kind, key, err)}
+	default:
+		return &violation{rule: rule, err: fmt.Errorf("could not get %s %s: %v", gvk.Kind, key, err)}
+	}
+
+	referenced := &ruleContext{ctx: rc.ctx, client: rc.client, cache: rc.cache, req: rc.req, object: obj.Object}
+	for i, sub := range rule.Lookup.Rules {
+		if vi := v.evaluate(referenced, sub); vi.denies() {
+			return vi.within(fmt.Sprintf("lookup(%s %s).rules[%d]", gvk.Kind, key, i))
+		}
+	}
+	return nil
+}
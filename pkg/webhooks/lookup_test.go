@@ -0,0 +1,96 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+)
+
+// objectsReader is a cache getting the objects by apiVersion, kind, namespace and name
+type objectsReader struct {
+	objects []map[string]interface{}
+}
+
+func (r *objectsReader) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	u := obj.(*unstructured.Unstructured)
+	for _, object := range r.objects {
+		o := &unstructured.Unstructured{Object: object}
+		if o.GroupVersionKind() == u.GroupVersionKind() && o.GetNamespace() == key.Namespace && o.GetName() == key.Name {
+			u.Object = object
+			return nil
+		}
+	}
+	return apierrors.NewNotFound(schema.GroupResource{Resource: u.GetKind()}, key.Name)
+}
+
+func (r *objectsReader) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	return nil
+}
+
+func scalable(apiVersion, kind, name string) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+	}
+}
+
+func hpa(apiVersion, kind, name string) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"scaleTargetRef": map[string]interface{}{"apiVersion": apiVersion, "kind": kind, "name": name},
+		},
+	}
+}
+
+func TestEvaluateLookup(t *testing.T) {
+	rule := config.Rule{Type: string(RuleTypeLookup), Lookup: &config.Lookup{
+		ApiVersion: "{{ object.spec.scaleTargetRef.apiVersion }}",
+		Kind:       "{{ object.spec.scaleTargetRef.kind }}",
+		Name:       "{{ object.spec.scaleTargetRef.name }}",
+	}}
+	if err := CompileRule(&rule); err != nil {
+		t.Fatalf("CompileRule(%v) failed: %v", rule, err)
+	}
+	cache := &objectsReader{objects: []map[string]interface{}{
+		scalable("apps/v1", "Deployment", "web"),
+		scalable("apps/v1", "StatefulSet", "db"),
+	}}
+	v := &genericValidator{log: logf.NullLogger{}}
+
+	tests := []struct {
+		name   string
+		object map[string]interface{}
+		denied bool
+	}{
+		{name: "deployment", object: hpa("apps/v1", "Deployment", "web")},
+		{name: "statefulset", object: hpa("apps/v1", "StatefulSet", "db")},
+		{name: "other kind", object: hpa("apps/v1", "StatefulSet", "web"), denied: true},
+		{name: "missing", object: hpa("apps/v1", "Deployment", "db"), denied: true},
+		{name: "invalid apiVersion", object: hpa("apps/v1/x", "Deployment", "web"), denied: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Namespace: "default",
+				Operation: admissionv1beta1.Create,
+			}}
+			rc := &ruleContext{ctx: context.Background(), cache: cache, req: req, object: tt.object}
+			vi := v.evaluate(rc, rule)
+			if vi.denies() != tt.denied {
+				t.Errorf("evaluate(%v) = %v, denied %v, want %v", rule, vi, vi.denies(), tt.denied)
+			}
+		})
+	}
+}
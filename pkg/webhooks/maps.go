@@ -0,0 +1,100 @@
+package webhooks
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+)
+
+// mapOperators are verified on the keys (or the values) of a map, i.e. metadata.labels
+var mapOperators = sets.NewString(
+	OperatorHasKey, OperatorHasAnyKey, OperatorHasAllKeys, OperatorKeysMatch, OperatorValuesMatch)
+
+// compileMapRule validates the rule value and compiles the patterns of KeysMatch and ValuesMatch
+func compileMapRule(rule *config.Rule, compiled *compiledRule) error {
+	values, ok := toStringSlice(rule.Value)
+	if !ok || len(values) == 0 {
+		return fmt.Errorf("Value (of type %T) in rule is not a string nor a list of strings", rule.Value)
+	}
+	if rule.Op == OperatorHasKey && len(values) != 1 {
+		return fmt.Errorf("Operator %s needs a single key", rule.Op)
+	}
+	if rule.Op != OperatorKeysMatch && rule.Op != OperatorValuesMatch {
+		return nil
+	}
+	for _, value := range values {
+		expr := value
+		if rule.IgnoreCase {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", value, err)
+		}
+		compiled.patterns = append(compiled.patterns, re)
+	}
+	return nil
+}
+
+// verifyMap verifies the map operators, the keys of all the maps the field path resolves to
+// are merged and a missing field is an empty map. For KeysMatch and ValuesMatch the quantifier
+// tells how many of the keys (or values) have to match
+func verifyMap(values []interface{}, rule config.Rule, compiled *compiledRule) (bool, error) {
+	entries := map[string]interface{}{}
+	for _, value := range values {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return false, fmt.Errorf("%v accessor error: %v is of the type %T, expected a map",
+				rule.Field, value, value)
+		}
+		for k, v := range m {
+			entries[k] = v
+		}
+	}
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	checkValues, _ := toStringSlice(rule.Value)
+
+	switch rule.Op {
+	case OperatorHasKey, OperatorHasAllKeys:
+		return sets.NewString(keys...).HasAll(checkValues...), nil
+	case OperatorHasAnyKey:
+		return sets.NewString(keys...).HasAny(checkValues...), nil
+	}
+
+	candidates := keys
+	if rule.Op == OperatorValuesMatch {
+		candidates = make([]string, 0, len(keys))
+		for _, k := range keys {
+			s, ok := entries[k].(string)
+			if !ok {
+				return false, fmt.Errorf("%v accessor error: value of %s is of the type %T, expected string",
+					rule.Field, k, entries[k])
+			}
+			candidates = append(candidates, s)
+		}
+	}
+	matching := 0
+	for _, candidate := range candidates {
+		for _, re := range compiled.patterns {
+			if re.MatchString(candidate) {
+				matching++
+				break
+			}
+		}
+	}
+	switch rule.Quantifier {
+	case QuantifierAny:
+		return matching > 0, nil
+	case QuantifierNone:
+		return matching == 0, nil
+	}
+	return matching == len(candidates), nil
+}
@@ -0,0 +1,103 @@
+package webhooks
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+)
+
+// parseNetwork parses a CIDR, or an IP as the network of just that address,
+// with onlyIP a CIDR is not accepted
+func parseNetwork(value interface{}, onlyIP bool) (*net.IPNet, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%v (of type %T) is not a string", value, value)
+	}
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		if onlyIP {
+			return nil, fmt.Errorf("%q is a CIDR and not an IP", s)
+		}
+		_, network, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", s)
+		}
+		return network, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP %q", s)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// compileNetworks parses the rule value, a string or a list of IPs or CIDRs
+func compileNetworks(rule *config.Rule) ([]*net.IPNet, error) {
+	values, ok := toStringSlice(rule.Value)
+	if !ok {
+		return nil, fmt.Errorf("Value (of type %T) in rule is not a string nor a list of strings", rule.Value)
+	}
+	networks := []*net.IPNet{}
+	for _, value := range values {
+		network, err := parseNetwork(value, false)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in rule: %v", err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func sameFamily(a, b *net.IPNet) bool { return len(a.IP) == len(b.IP) }
+
+// networkContains is true if inner is all inside outer
+func networkContains(outer, inner *net.IPNet) bool {
+	outerOnes, _ := outer.Mask.Size()
+	innerOnes, _ := inner.Mask.Size()
+	return sameFamily(outer, inner) && outer.Contains(inner.IP) && innerOnes >= outerOnes
+}
+
+func networksOverlap(a, b *net.IPNet) bool {
+	return sameFamily(a, b) && (a.Contains(b.IP) || b.Contains(a.IP))
+}
+
+func networksEqual(a, b *net.IPNet) bool {
+	return a.IP.Equal(b.IP) && a.Mask.String() == b.Mask.String()
+}
+
+// verifyNetwork verifies an IP or a CIDR against the networks in the rule
+func verifyNetwork(value interface{}, rule config.Rule, compiled *compiledRule) (bool, error) {
+	val, err := parseNetwork(value, rule.Type == ValueTypeIP)
+	if err != nil {
+		return false, &invalidValueError{field: rule.Field, err: err}
+	}
+	var check func(a, b *net.IPNet) bool
+	negated := false
+	switch rule.Op {
+	case OperatorIs, OperatorIn:
+		check = networksEqual
+	case OperatorIsNot, OperatorNotIn:
+		check, negated = networksEqual, true
+	case OperatorInCIDR:
+		check = func(val, network *net.IPNet) bool { return networkContains(network, val) }
+	case OperatorNotInCIDR:
+		check, negated = func(val, network *net.IPNet) bool { return networkContains(network, val) }, true
+	case OperatorOverlaps:
+		check = networksOverlap
+	case OperatorNotOverlaps:
+		check, negated = networksOverlap, true
+	default:
+		return false, fmt.Errorf("unknown operator %s for type %s", rule.Op, rule.Type)
+	}
+	for _, network := range compiled.networks {
+		if check(val, network) {
+			return !negated, nil
+		}
+	}
+	return negated, nil
+}
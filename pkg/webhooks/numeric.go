@@ -0,0 +1,232 @@
+package webhooks
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+)
+
+// toInt64 coerces a number, from yaml or json, or a numeric string to int64,
+// a float has to be integral
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt64 && v <= math.MaxInt64 {
+			return int64(v), true
+		}
+	case string:
+		if i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+			return i, true
+		}
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return toInt64(f)
+		}
+	}
+	return 0, false
+}
+
+// toFloat64 coerces a number, from yaml or json, or a numeric string to float64
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+// compareResult is the result of Is, IsNot or an ordering operator, given the comparison
+// (-1, 0 or 1) of the field value with the rule one, false if op is not one of them
+func compareResult(op Operator, cmp int) (result bool, found bool) {
+	switch op {
+	case OperatorIsNot:
+		return cmp != 0, true
+	case OperatorIs:
+		return cmp == 0, true
+	case OperatorGreaterThan, OperatorMoreThan:
+		return cmp > 0, true
+	case OperatorSmallerThan, OperatorLessThan:
+		return cmp < 0, true
+	case OperatorEqualOrMoreThan, OperatorEqualOrGreaterThan:
+		return cmp >= 0, true
+	case OperatorEqualOrLessThan, OperatorEqualOrSmallerThan:
+		return cmp <= 0, true
+	}
+	return false, false
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloat64(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// verifyNumber verifies the int and float types, the rule and the field values are coerced
+// from any number or numeric string
+func verifyNumber(value interface{}, rule config.Rule) (bool, error) {
+	if rule.Op == OperatorIn || rule.Op == OperatorNotIn {
+		return verifyIn(value, rule)
+	}
+	var cmp int
+	if rule.Type == ValueTypeInt || rule.Type == ValueTypeInt64 {
+		checkValue, ok := toInt64(rule.Value)
+		if !ok {
+			return false, fmt.Errorf("Value (of type %T) in rule is not of type: %s", rule.Value, rule.Type)
+		}
+		val, ok := toInt64(value)
+		if !ok {
+			return false, &invalidValueError{field: rule.Field, err: fmt.Errorf("%v (of type %T) is not an int", value, value)}
+		}
+		cmp = compareInt64(val, checkValue)
+	} else {
+		checkValue, ok := toFloat64(rule.Value)
+		if !ok {
+			return false, fmt.Errorf("Value (of type %T) in rule is not of type: %s", rule.Value, rule.Type)
+		}
+		val, ok := toFloat64(value)
+		if !ok {
+			return false, &invalidValueError{field: rule.Field, err: fmt.Errorf("%v (of type %T) is not a number", value, value)}
+		}
+		cmp = compareFloat64(val, checkValue)
+	}
+	if result, found := compareResult(rule.Op, cmp); found {
+		return result, nil
+	}
+	return false, fmt.Errorf("unknown operator %s for type %s", rule.Op, rule.Type)
+}
+
+// intOrString is a value that can be an int, a percentage (i.e. 25%) or a name (i.e. a named port)
+type intOrString struct {
+	intVal  int64
+	percent bool
+	name    string
+}
+
+func (v intOrString) isName() bool { return v.name != "" }
+
+func (v intOrString) String() string {
+	switch {
+	case v.isName():
+		return v.name
+	case v.percent:
+		return fmt.Sprintf("%d%%", v.intVal)
+	}
+	return strconv.FormatInt(v.intVal, 10)
+}
+
+func parseIntOrString(value interface{}) (intOrString, error) {
+	if s, ok := value.(string); ok {
+		s = strings.TrimSpace(s)
+		if strings.HasSuffix(s, "%") {
+			if i, err := strconv.ParseInt(strings.TrimSuffix(s, "%"), 10, 64); err == nil {
+				return intOrString{intVal: i, percent: true}, nil
+			}
+		}
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return intOrString{intVal: i}, nil
+		}
+		if s == "" {
+			return intOrString{}, fmt.Errorf("empty string is not an int or a name")
+		}
+		return intOrString{name: s}, nil
+	}
+	if i, ok := toInt64(value); ok {
+		return intOrString{intVal: i}, nil
+	}
+	return intOrString{}, fmt.Errorf("%v (of type %T) is not an int or a string", value, value)
+}
+
+// compileIntOrString parses the rule value, a list for In and NotIn, the ordering operators
+// need an int or a percentage
+func compileIntOrString(rule *config.Rule) ([]interface{}, error) {
+	values := []interface{}{rule.Value}
+	if rule.Op == OperatorIn || rule.Op == OperatorNotIn {
+		items, ok := rule.Value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Value (of type %T) in rule is not a list", rule.Value)
+		}
+		values = items
+	}
+	parsed := []interface{}{}
+	for _, value := range values {
+		v, err := parseIntOrString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in rule: %v", err)
+		}
+		if v.isName() && rule.Op != OperatorIs && rule.Op != OperatorIsNot &&
+			rule.Op != OperatorIn && rule.Op != OperatorNotIn {
+			return nil, fmt.Errorf("Operator %s needs an int or a percentage, not the name %q", rule.Op, v.name)
+		}
+		parsed = append(parsed, v)
+	}
+	return parsed, nil
+}
+
+// verifyIntOrString compares ints (also numeric strings) and percentages numerically and
+// names as strings, a name (i.e. a named port) in the field is not valid for the ordering
+// operators and neither a percentage compared with an int, the invalid policy applies
+func verifyIntOrString(value interface{}, rule config.Rule, checkValues []interface{}) (bool, error) {
+	if len(checkValues) == 0 {
+		return false, fmt.Errorf("no value in rule for type %s", rule.Type)
+	}
+	val, err := parseIntOrString(value)
+	if err != nil {
+		return false, &invalidValueError{field: rule.Field, err: err}
+	}
+	switch rule.Op {
+	case OperatorIn, OperatorNotIn:
+		found := false
+		for _, checkValue := range checkValues {
+			if val == checkValue.(intOrString) {
+				found = true
+				break
+			}
+		}
+		return found == (rule.Op == OperatorIn), nil
+	case OperatorIs:
+		return val == checkValues[0].(intOrString), nil
+	case OperatorIsNot:
+		return val != checkValues[0].(intOrString), nil
+	}
+	checkValue := checkValues[0].(intOrString)
+	if val.isName() {
+		return false, &invalidValueError{field: rule.Field,
+			err: fmt.Errorf("the name %q can't be compared with %s", val.name, checkValue)}
+	}
+	if val.percent != checkValue.percent {
+		return false, &invalidValueError{field: rule.Field,
+			err: fmt.Errorf("%s can't be compared with %s", val, checkValue)}
+	}
+	if result, found := compareResult(rule.Op, compareInt64(val.intVal, checkValue.intVal)); found {
+		return result, nil
+	}
+	return false, fmt.Errorf("unknown operator %s for type %s", rule.Op, rule.Type)
+}
@@ -0,0 +1,233 @@
+package webhooks
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+)
+
+// invalidValueError is returned when the field value is not valid for the rule type,
+// the rule invalid policy is applied
+type invalidValueError struct {
+	field string
+	err   error
+}
+
+func (e *invalidValueError) Error() string {
+	return fmt.Sprintf("%s has an invalid value: %v", e.field, e.err)
+}
+
+// orderedType is a value type whose values are parsed from the rule and from the field
+// and then compared, the rule values are parsed once by CompileRule
+type orderedType struct {
+	parse func(value interface{}) (interface{}, error)
+	// parseRule parses the rule values when they have more forms than the field ones
+	parseRule func(value interface{}) (interface{}, error)
+	// compare returns -1, 0 or 1 if a is less, equal or greater than b
+	compare func(a, b interface{}) int
+}
+
+var orderedTypes = map[ValueType]orderedType{
+	ValueTypeQuantity:  {parse: parseQuantity, compare: compareQuantity},
+	ValueTypeDuration:  {parse: parseDuration, compare: compareDuration},
+	ValueTypeTimestamp: {parse: parseTimestamp, parseRule: parseRuleTimestamp, compare: compareTimestamp},
+	ValueTypeSemver:    {parse: parseSemver, compare: compareSemver},
+}
+
+func parseQuantity(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return resource.ParseQuantity(v)
+	case int:
+		return *resource.NewQuantity(int64(v), resource.DecimalSI), nil
+	case int64:
+		return *resource.NewQuantity(v, resource.DecimalSI), nil
+	case float64:
+		return resource.ParseQuantity(strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	return nil, fmt.Errorf("%v (of type %T) is not a quantity", value, value)
+}
+
+func compareQuantity(a, b interface{}) int {
+	qa, qb := a.(resource.Quantity), b.(resource.Quantity)
+	return qa.Cmp(qb)
+}
+
+// daysWeeksRe matches the days and weeks at the beginning of a duration, unknown to time.ParseDuration
+var daysWeeksRe = regexp.MustCompile(`^([0-9]+)([dw])`)
+
+// parseDurationString parses a go duration also accepting days (d) and weeks (w) (i.e. 1w2d12h)
+func parseDurationString(s string) (time.Duration, error) {
+	var total time.Duration
+	rest := s
+	for m := daysWeeksRe.FindStringSubmatch(rest); m != nil; m = daysWeeksRe.FindStringSubmatch(rest) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %v", s, err)
+		}
+		unit := 24 * time.Hour
+		if m[2] == "w" {
+			unit *= 7
+		}
+		total += time.Duration(n) * unit
+		rest = rest[len(m[0]):]
+	}
+	if rest == "" && s != "" {
+		return total, nil
+	}
+	d, err := time.ParseDuration(rest)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %v", s, err)
+	}
+	return total + d, nil
+}
+
+// parseDuration parses a duration string or a number of seconds
+func parseDuration(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return parseDurationString(v)
+	case int:
+		return time.Duration(v) * time.Second, nil
+	case int64:
+		return time.Duration(v) * time.Second, nil
+	case float64:
+		return time.Duration(v * float64(time.Second)), nil
+	}
+	return nil, fmt.Errorf("%v (of type %T) is not a duration", value, value)
+}
+
+func compareDuration(a, b interface{}) int {
+	da, db := a.(time.Duration), b.(time.Duration)
+	switch {
+	case da < db:
+		return -1
+	case da > db:
+		return 1
+	}
+	return 0
+}
+
+// relativeTime is a timestamp relative to when it is compared, like now+30d
+type relativeTime time.Duration
+
+func (r relativeTime) resolve() time.Time { return time.Now().Add(time.Duration(r)) }
+
+// parseTimestamp parses a RFC3339 time
+func parseTimestamp(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case time.Time:
+		// yaml is decoding unquoted timestamps
+		return v, nil
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q: %v", v, err)
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf("%v (of type %T) is not a timestamp", value, value)
+}
+
+// parseRuleTimestamp parses a rule value, a RFC3339 time or a relative one (now, now+30d, now-1h)
+func parseRuleTimestamp(value interface{}) (interface{}, error) {
+	v, ok := value.(string)
+	if !ok || !strings.HasPrefix(v, "now") {
+		return parseTimestamp(value)
+	}
+	offset := strings.TrimSpace(strings.TrimPrefix(v, "now"))
+	if offset == "" {
+		return relativeTime(0), nil
+	}
+	sign := time.Duration(1)
+	switch offset[0] {
+	case '-':
+		sign = -1
+	case '+':
+	default:
+		return nil, fmt.Errorf("invalid relative time %q", v)
+	}
+	d, err := parseDurationString(strings.TrimSpace(offset[1:]))
+	if err != nil {
+		return nil, err
+	}
+	return relativeTime(sign * d), nil
+}
+
+func compareTimestamp(a, b interface{}) int {
+	toTime := func(v interface{}) time.Time {
+		if r, ok := v.(relativeTime); ok {
+			return r.resolve()
+		}
+		return v.(time.Time)
+	}
+	ta, tb := toTime(a), toTime(b)
+	switch {
+	case ta.Before(tb):
+		return -1
+	case ta.After(tb):
+		return 1
+	}
+	return 0
+}
+
+// compileOrdered parses the rule value, a list for In and NotIn
+func compileOrdered(rule *config.Rule, ot orderedType) ([]interface{}, error) {
+	values := []interface{}{rule.Value}
+	if rule.Op == OperatorIn || rule.Op == OperatorNotIn {
+		items, ok := rule.Value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Value (of type %T) in rule is not a list", rule.Value)
+		}
+		values = items
+	}
+	parsed := []interface{}{}
+	for _, value := range values {
+		// an unquoted version is decoded as a number, losing i.e. the trailing 0 of 1.10
+		if _, isString := value.(string); rule.Type == ValueTypeSemver && !isString {
+			return nil, fmt.Errorf("semver %v in rule has to be quoted", value)
+		}
+		parse := ot.parse
+		if ot.parseRule != nil {
+			parse = ot.parseRule
+		}
+		p, err := parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in rule: %v", err)
+		}
+		parsed = append(parsed, p)
+	}
+	return parsed, nil
+}
+
+// verifyOrdered verifies a field value against the parsed rule values
+func verifyOrdered(value interface{}, rule config.Rule, ot orderedType, checkValues []interface{}) (bool, error) {
+	if len(checkValues) == 0 {
+		return false, fmt.Errorf("no value in rule for type %s", rule.Type)
+	}
+	val, err := ot.parse(value)
+	if err != nil {
+		return false, &invalidValueError{field: rule.Field, err: err}
+	}
+	switch rule.Op {
+	case OperatorIn, OperatorNotIn:
+		found := false
+		for _, checkValue := range checkValues {
+			if ot.compare(val, checkValue) == 0 {
+				found = true
+				break
+			}
+		}
+		return found == (rule.Op == OperatorIn), nil
+	}
+	if result, found := compareResult(rule.Op, ot.compare(val, checkValues[0])); found {
+		return result, nil
+	}
+	return false, fmt.Errorf("unknown operator %s for type %s", rule.Op, rule.Type)
+}
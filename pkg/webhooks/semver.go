@@ -0,0 +1,51 @@
+package webhooks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+)
+
+// imageTag returns the tag of an image reference (istio/proxyv2:1.7.0@sha256:...),
+// or the value itself if it is not an image reference
+func imageTag(value string) string {
+	if i := strings.Index(value, "@"); i >= 0 {
+		value = value[:i]
+	}
+	if i := strings.LastIndex(value, ":"); i >= 0 && !strings.Contains(value[i:], "/") {
+		return value[i+1:]
+	}
+	return value
+}
+
+func parseSemver(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		version, err := semver.NewVersion(imageTag(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid semver %q: %v", v, err)
+		}
+		return version, nil
+	case int, int64:
+		// yaml and json are decoding 1 as a number, a float (1.10 is 1.1) can't be trusted
+		return semver.NewVersion(fmt.Sprint(v))
+	}
+	return nil, fmt.Errorf("%v (of type %T) is not a semver", value, value)
+}
+
+func compareSemver(a, b interface{}) int {
+	return a.(*semver.Version).Compare(b.(*semver.Version))
+}
+
+// verifySemverRange verifies the range operators
+func verifySemverRange(value interface{}, rule config.Rule, constraint *semver.Constraints) (bool, error) {
+	version, err := parseSemver(value)
+	if err != nil {
+		return false, &invalidValueError{field: rule.Field, err: err}
+	}
+	inRange := constraint.Check(version.(*semver.Version))
+	return inRange == (rule.Op == OperatorInRange), nil
+}
@@ -0,0 +1,216 @@
+package webhooks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+	"github.com/safanaj/k8s-generic-validator/pkg/utils/fieldpath"
+)
+
+// templateRoots are what the expressions of a template can refer to: the object and the old one,
+// the admission request (i.e. request.userInfo.username) and the metadata of the namespace
+// of the request (i.e. namespace.labels.team)
+var templateRoots = []string{"object", "oldObject", "request", "namespace"}
+
+// valueTemplate is a string with {{ root.fieldpath }} expressions (i.e. {{ object.spec.serviceName }})
+type valueTemplate struct {
+	raw   string
+	parts []templatePart
+}
+
+// templatePart is a literal string or an expression
+type templatePart struct {
+	literal string
+	root    string
+	path    *fieldpath.Path
+}
+
+// missingValueError is returned when the field of a rule, or what a rule value is taken from,
+// resolves to nothing
+type missingValueError struct {
+	field string
+}
+
+func (e *missingValueError) Error() string {
+	return fmt.Sprintf("Field not found at %s", e.field)
+}
+
+func isMissingValue(err error) bool {
+	_, isMissing := err.(*missingValueError)
+	return isMissing
+}
+
+func isTemplate(s string) bool { return strings.Contains(s, "{{") }
+
+// hasTemplates is true for a string or a list with strings that are templates
+func hasTemplates(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return isTemplate(v)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && isTemplate(s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compileValueTemplates compiles a rule value that is a template or a list of templates
+func compileValueTemplates(value interface{}) ([]*valueTemplate, error) {
+	values, ok := toStringSlice(value)
+	if !ok {
+		return nil, fmt.Errorf("Value (of type %T) in rule with templates is not a string nor a list of strings", value)
+	}
+	templates := make([]*valueTemplate, 0, len(values))
+	for _, value := range values {
+		t, err := compileTemplate(value)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
+
+func compileTemplate(s string) (*valueTemplate, error) {
+	t := &valueTemplate{raw: s}
+	for rest := s; rest != ""; {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			t.parts = append(t.parts, templatePart{literal: rest})
+			break
+		}
+		end := strings.Index(rest[start:], "}}")
+		if end < 0 {
+			return nil, fmt.Errorf("invalid template %q: unterminated {{", s)
+		}
+		if start > 0 {
+			t.parts = append(t.parts, templatePart{literal: rest[:start]})
+		}
+		part, err := compileTemplateExpr(strings.TrimSpace(rest[start+2 : start+end]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid template %q: %v", s, err)
+		}
+		t.parts = append(t.parts, part)
+		rest = rest[start+end+2:]
+	}
+	return t, nil
+}
+
+func compileTemplateExpr(expr string) (templatePart, error) {
+	for _, root := range templateRoots {
+		if expr == root || strings.HasPrefix(expr, root+".") {
+			path, err := fieldpath.Parse(strings.TrimPrefix(strings.TrimPrefix(expr, root), "."))
+			if err != nil {
+				return templatePart{}, err
+			}
+			if path.IsMulti() {
+				return templatePart{}, fmt.Errorf("%s has to address a single value", expr)
+			}
+			return templatePart{root: root, path: path}, nil
+		}
+	}
+	return templatePart{}, fmt.Errorf("%s does not start with one of %s", expr, strings.Join(templateRoots, ", "))
+}
+
+// resolve returns the value of an expression
+func (rc *ruleContext) resolve(part templatePart) (interface{}, error) {
+	var obj map[string]interface{}
+	var err error
+	switch part.root {
+	case "object":
+		obj = rc.object
+	case "oldObject":
+		obj = rc.oldObject
+	case "request":
+		obj, err = rc.requestObject()
+	case "namespace":
+		var ns map[string]interface{}
+		if ns, err = rc.namespaceObject(); ns != nil {
+			obj, _ = ns["metadata"].(map[string]interface{})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	values := part.path.Resolve(obj)
+	if len(values) == 0 || values[0] == nil {
+		return nil, &missingValueError{field: fmt.Sprintf("%s.%s", part.root, part.path)}
+	}
+	return values[0], nil
+}
+
+// render returns the template with the expressions replaced by their values
+func (t *valueTemplate) render(rc *ruleContext) (string, error) {
+	var b strings.Builder
+	for _, part := range t.parts {
+		if part.path == nil {
+			b.WriteString(part.literal)
+			continue
+		}
+		value, err := rc.resolve(part)
+		if err != nil {
+			return "", err
+		}
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return "", fmt.Errorf("%s.%s in %q is not a scalar value", part.root, part.path, t.raw)
+		}
+		fmt.Fprint(&b, value)
+	}
+	return b.String(), nil
+}
+
+// resolveValue returns the rule with the value taken from the valueFrom field or ConfigMap, or rendered
+// from the templates, compiled as the value was in the configuration
+func (v *genericValidator) resolveValue(rc *ruleContext, rule config.Rule, compiled *compiledRule) (config.Rule, *compiledRule, error) {
+	resolved := rule
+	resolved.ValueFrom, resolved.Compiled = nil, nil
+	switch {
+	case rule.ValueFrom != nil && rule.ValueFrom.ConfigMapRef != nil:
+		ref := rule.ValueFrom.ConfigMapRef
+		values, found := v.cfg.GetConfigMapValues(ref)
+		if !found {
+			return rule, nil, &missingValueError{field: "configMap " + ref.String()}
+		}
+		resolved.Value = values
+	case compiled.valueFrom != nil:
+		refObj := rc.object
+		if compiled.valueFromOld {
+			refObj = rc.oldObject
+		}
+		refValues := compiled.valueFrom.Resolve(refObj)
+		if len(refValues) == 0 {
+			return rule, nil, &missingValueError{field: rule.ValueFrom.Field}
+		}
+		resolved.Value = refValues[0]
+	case compiled.valueTemplates != nil:
+		values := make([]interface{}, 0, len(compiled.valueTemplates))
+		for _, t := range compiled.valueTemplates {
+			value, err := t.render(rc)
+			if err != nil {
+				return rule, nil, err
+			}
+			values = append(values, value)
+		}
+		resolved.Value = values
+		if _, isList := rule.Value.([]interface{}); !isList {
+			resolved.Value = values[0]
+		}
+	default:
+		return rule, compiled, nil
+	}
+	if err := compileRule(&resolved, false); err != nil {
+		if rule.ValueFrom != nil && rule.ValueFrom.ConfigMapRef != nil {
+			return rule, nil, fmt.Errorf("valueFrom configMap %s: %v", rule.ValueFrom.ConfigMapRef, err)
+		}
+		if rule.ValueFrom != nil {
+			return rule, nil, fmt.Errorf("valueFrom %s: %v", rule.ValueFrom.Field, err)
+		}
+		return rule, nil, err
+	}
+	return resolved, resolved.Compiled.(*compiledRule), nil
+}
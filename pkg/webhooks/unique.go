@@ -0,0 +1,195 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+	"github.com/safanaj/k8s-generic-validator/pkg/utils/fieldpath"
+)
+
+// uniqueIndexes are the kinds and fields indexed in the cache for the unique rules, the indexes
+// can be added just before the cache is started so the rules configured later (or for kinds
+// without an explicit apiVersion) list all the objects of the kind, unindexed are the kinds
+// and fields that were warned about for that
+var uniqueIndexes = struct {
+	sync.RWMutex
+	fields    map[schema.GroupVersionKind]sets.String
+	unindexed map[schema.GroupVersionKind]sets.String
+}{fields: map[schema.GroupVersionKind]sets.String{}, unindexed: map[schema.GroupVersionKind]sets.String{}}
+
+func uniqueIndexName(field string) string { return "unique:" + field }
+
+func isUniqueIndexed(gvk schema.GroupVersionKind, field string) bool {
+	uniqueIndexes.RLock()
+	defer uniqueIndexes.RUnlock()
+	return uniqueIndexes.fields[gvk].Has(field)
+}
+
+// warnUnindexed logs, once for a kind and field, that a unique rule lists all the objects of the kind
+func (v *genericValidator) warnUnindexed(gvk schema.GroupVersionKind, field string) {
+	uniqueIndexes.Lock()
+	defer uniqueIndexes.Unlock()
+	if uniqueIndexes.unindexed[gvk].Has(field) {
+		return
+	}
+	if uniqueIndexes.unindexed[gvk] == nil {
+		uniqueIndexes.unindexed[gvk] = sets.NewString()
+	}
+	uniqueIndexes.unindexed[gvk].Insert(field)
+	// the index is added at the next start if the rule set has an explicit apiVersion and kind
+	v.log.Info("unique rule not indexed, listing all the objects of the kind", "kind", gvk, "field", field)
+}
+
+// uniqueValues returns the scalar values a field path resolves to, as strings
+func uniqueValues(obj map[string]interface{}, path *fieldpath.Path) []string {
+	values := []string{}
+	for _, value := range flatten(path.Resolve(obj)) {
+		switch value.(type) {
+		case nil, map[string]interface{}, []interface{}:
+			continue
+		}
+		values = append(values, fmt.Sprint(value))
+	}
+	return sets.NewString(values...).List()
+}
+
+// uniqueRules returns the rules of type unique, also the nested ones
+func uniqueRules(rules []config.Rule) []config.Rule {
+	found := []config.Rule{}
+	for _, rule := range rules {
+		if rule.Type == RuleTypeUnique {
+			found = append(found, rule)
+		}
+		found = append(found, uniqueRules(rule.AllOf)...)
+		found = append(found, uniqueRules(rule.AnyOf)...)
+		if rule.Not != nil {
+			found = append(found, uniqueRules([]config.Rule{*rule.Not})...)
+		}
+	}
+	return found
+}
+
+// SetupUniqueIndexes adds to the cache an index for every field of the unique rules of the
+// rule sets with an explicit apiVersion and kind, it has to be called before the cache is started.
+// The unique rules of the other rule sets, or configured later, are verified listing all the
+// objects of the kind, with a warning logged the first time
+func SetupUniqueIndexes(indexer client.FieldIndexer, cfg *config.Config) error {
+	uniqueIndexes.Lock()
+	defer uniqueIndexes.Unlock()
+	for _, ruleSet := range cfg.GetAllRuleSets() {
+		gv, err := schema.ParseGroupVersion(ruleSet.ApiVersion)
+		if err != nil || ruleSet.ApiVersion == "" || gv.Group == config.Any || gv.Version == config.Any || ruleSet.Kind == "" {
+			continue
+		}
+		gvk := gv.WithKind(ruleSet.Kind)
+		for _, rule := range uniqueRules(ruleSet.Rules) {
+			if uniqueIndexes.fields[gvk].Has(rule.Field) {
+				continue
+			}
+			compiled, err := getCompiled(rule)
+			if err != nil {
+				return err
+			}
+			path := compiled.path
+			obj := &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(gvk)
+			if err := indexer.IndexField(context.Background(), obj, uniqueIndexName(rule.Field), func(o runtime.Object) []string {
+				u, ok := o.(*unstructured.Unstructured)
+				if !ok {
+					return nil
+				}
+				return uniqueValues(u.Object, path)
+			}); err != nil {
+				return fmt.Errorf("could not index %s for %s: %v", rule.Field, gvk, err)
+			}
+			if uniqueIndexes.fields[gvk] == nil {
+				uniqueIndexes.fields[gvk] = sets.NewString()
+			}
+			uniqueIndexes.fields[gvk].Insert(rule.Field)
+		}
+	}
+	return nil
+}
+
+// evaluateUnique looks in the cache for other objects of the kind having some of the values
+// of the field, the violation names the first one found. Removing an object is always fine
+// and on UPDATE only the values that were not in the old object are looked for, so an object
+// that already conflicts (i.e. created before the rule) can still be updated
+func (v *genericValidator) evaluateUnique(rc *ruleContext, rule config.Rule) *violation {
+	if rc.req.Operation == admissionv1beta1.Delete {
+		return nil
+	}
+	compiled, err := getCompiled(rule)
+	if err != nil {
+		return &violation{rule: rule, err: err}
+	}
+	values := uniqueValues(rc.object, compiled.path)
+	if rc.oldObject != nil {
+		values = sets.NewString(values...).Difference(sets.NewString(uniqueValues(rc.oldObject, compiled.path)...)).List()
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	gvk := schema.GroupVersionKind(rc.req.Kind)
+	self := &unstructured.Unstructured{Object: rc.object}
+	name := self.GetName()
+	if name == "" {
+		name = rc.req.Name
+	}
+	opts := []client.ListOption{}
+	if rule.Scope == ScopeNamespace {
+		opts = append(opts, client.InNamespace(rc.req.Namespace))
+	}
+	// with an index the objects are listed by value, otherwise all of them once
+	indexed := isUniqueIndexed(gvk, rule.Field)
+	listValues := []string{""}
+	if indexed {
+		listValues = values
+	} else {
+		v.warnUnindexed(gvk, rule.Field)
+	}
+
+	ctx, cancel := context.WithTimeout(rc.ctx, defaultLookupTimeout)
+	defer cancel()
+	for _, listValue := range listValues {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		listOpts := opts
+		if indexed {
+			listOpts = append(append([]client.ListOption{}, opts...),
+				client.MatchingFields{uniqueIndexName(rule.Field): listValue})
+		}
+		if err := rc.cache.List(ctx, list, listOpts...); err != nil {
+			return &violation{rule: rule, err: fmt.Errorf("could not list %s: %v", gvk.Kind, err)}
+		}
+		for _, item := range list.Items {
+			if item.GetNamespace() == rc.req.Namespace && item.GetName() == name {
+				continue
+			}
+			used := sets.NewString(uniqueValues(item.Object, compiled.path)...)
+			for _, value := range values {
+				if used.Has(value) {
+					return &violation{rule: rule, err: fmt.Errorf("%s %q is already used by %s %s",
+						rule.Field, value, gvk.Kind, objectKey(&item))}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func objectKey(u *unstructured.Unstructured) string {
+	if u.GetNamespace() == "" {
+		return u.GetName()
+	}
+	return u.GetNamespace() + "/" + u.GetName()
+}
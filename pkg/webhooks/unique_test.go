@@ -0,0 +1,87 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+)
+
+// listReader is a cache listing always the same objects
+type listReader struct {
+	items []unstructured.Unstructured
+}
+
+func (r *listReader) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	return nil
+}
+
+func (r *listReader) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	list.(*unstructured.UnstructuredList).Items = r.items
+	return nil
+}
+
+func ingress(name string, hosts ...interface{}) map[string]interface{} {
+	rules := []interface{}{}
+	for _, host := range hosts {
+		rules = append(rules, map[string]interface{}{"host": host})
+	}
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{"name": name, "namespace": "default"},
+		"spec":     map[string]interface{}{"rules": rules},
+	}
+}
+
+func TestEvaluateUnique(t *testing.T) {
+	rule := config.Rule{Field: "spec.rules[*].host", Type: string(RuleTypeUnique)}
+	if err := CompileRule(&rule); err != nil {
+		t.Fatalf("CompileRule(%v) failed: %v", rule, err)
+	}
+	// web conflicts with other on a.example.com
+	cache := &listReader{items: []unstructured.Unstructured{
+		{Object: ingress("web", "a.example.com")},
+		{Object: ingress("other", "a.example.com")},
+	}}
+	v := &genericValidator{log: logf.NullLogger{}}
+
+	tests := []struct {
+		name      string
+		operation admissionv1beta1.Operation
+		object    map[string]interface{}
+		oldObject map[string]interface{}
+		denied    bool
+	}{
+		{name: "create with a used host", operation: admissionv1beta1.Create,
+			object: ingress("new", "a.example.com"), denied: true},
+		{name: "create with a free host", operation: admissionv1beta1.Create,
+			object: ingress("new", "b.example.com")},
+		{name: "delete a conflicting object", operation: admissionv1beta1.Delete,
+			object: ingress("web", "a.example.com"), oldObject: ingress("web", "a.example.com")},
+		{name: "update keeping a conflicting host", operation: admissionv1beta1.Update,
+			object: ingress("web", "a.example.com", "b.example.com"), oldObject: ingress("web", "a.example.com")},
+		{name: "update adding a used host", operation: admissionv1beta1.Update,
+			object: ingress("other", "a.example.com"), oldObject: ingress("other"), denied: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Kind:      metav1.GroupVersionKind{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"},
+				Namespace: "default",
+				Operation: tt.operation,
+			}}
+			rc := &ruleContext{ctx: context.Background(), cache: cache, req: req, object: tt.object, oldObject: tt.oldObject}
+			vi := v.evaluate(rc, rule)
+			if vi.denies() != tt.denied {
+				t.Errorf("evaluate(%v) = %v, denied %v, want %v", rule, vi, vi.denies(), tt.denied)
+			}
+		})
+	}
+}
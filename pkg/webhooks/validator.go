@@ -6,9 +6,10 @@ import (
 	"net/http"
 	"strings"
 
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	authv1 "k8s.io/api/authentication/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	_ "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -21,13 +22,14 @@ import (
 // validates entry of namespaces
 type genericValidator struct {
 	Client  client.Client
+	Cache   client.Reader
 	decoder *admission.Decoder
 	log     logr.Logger
 	cfg     *config.Config
 }
 
-func NewGenericValidator(c client.Client, log logr.Logger, cfg *config.Config) admission.Handler {
-	return &genericValidator{Client: c, log: log, cfg: cfg}
+func NewGenericValidator(c client.Client, cache client.Reader, log logr.Logger, cfg *config.Config) admission.Handler {
+	return &genericValidator{Client: c, Cache: cache, log: log, cfg: cfg}
 }
 
 var _ admission.Handler = &genericValidator{}
@@ -47,27 +49,71 @@ func (v *genericValidator) Handle(ctx context.Context, req admission.Request) ad
 
 	v.log.Info("Handle", "req", req)
 
-	err := v.decoder.Decode(req, u)
-	if err != nil {
-		return admission.Errored(http.StatusBadRequest, err)
-	}
-
-	v.log.Info("Handle req is ok", "req", req, "obj", u.Object, "userinfo", req.UserInfo)
 	// check user info
 	if isClusterAdmin(req.UserInfo, v.cfg.GetAdminGroups()) {
 		v.log.Info("Handle Allow cluster admin")
 		return admission.Allowed("")
 	}
 
-	if rules := v.cfg.GetRulesForKind(u.GetKind()); len(rules) > 0 {
-		for _, rule := range rules {
-			if ok, err := v.verify(u.Object, rule); !ok || err != nil {
+	// match on what the api server is reporting, the object kind could be not set (i.e. on DELETE)
+	gvk := schema.GroupVersionKind(req.Kind)
+	gvr := schema.GroupVersionResource(req.Resource)
+	ruleSets := v.cfg.GetRuleSetsFor(gvk, gvr, string(req.Operation))
+	if len(ruleSets) == 0 {
+		v.log.Info("Handle Allow, no rules for the operation", "operation", req.Operation)
+		return admission.Allowed("")
+	}
+
+	// on DELETE the object is not sent, the rules are verified against the existing one, that is
+	// also the old object
+	raw := req.Object
+	if req.Operation == admissionv1beta1.Delete {
+		raw = req.OldObject
+	}
+	err := v.decoder.DecodeRaw(raw, u)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	rc := &ruleContext{ctx: ctx, client: v.Client, cache: v.Cache, req: req, object: u.Object}
+	if req.Operation == admissionv1beta1.Delete {
+		rc.oldObject = u.Object
+	} else if len(req.OldObject.Raw) > 0 {
+		old := &unstructured.Unstructured{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		rc.oldObject = old.Object
+	}
+
+	v.log.Info("Handle req is ok", "req", req, "obj", u.Object, "userinfo", req.UserInfo)
+	name := u.GetName()
+	if name == "" {
+		name = req.Name
+	}
+	for _, ruleSet := range ruleSets {
+		applies, err := ruleSet.Applies(req.Namespace, name, u.GetLabels(), rc.namespaceLabels)
+		if err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		if !applies {
+			v.log.Info("Handle skip rules, object not matching", "kind", ruleSet.Kind, "resource", ruleSet.Resource)
+			continue
+		}
+		for _, rule := range ruleSet.Rules {
+			if vi := v.evaluate(rc, rule); vi.denies() {
 				var denyMsg string
-				if err != nil {
-					denyMsg = fmt.Sprintf("The error %v occurred verifing the rule: %v", err, rule)
+				if vi.err != nil {
+					denyMsg = fmt.Sprintf("The error %v occurred verifing the rule: %v", vi.err, rule)
 				} else {
 					denyMsg = fmt.Sprintf("Rule: %v violated", rule)
 				}
+				if vi.branch != "" {
+					denyMsg = fmt.Sprintf("%s, at %s", denyMsg, vi.where())
+				}
+				if len(vi.reasons) > 0 {
+					denyMsg = fmt.Sprintf("%s: %s", denyMsg, strings.Join(vi.reasons, "; "))
+				}
 				return admission.Denied(denyMsg)
 			}
 		}
@@ -83,47 +129,119 @@ func isClusterAdmin(userInfo authv1.UserInfo, adminGroups []string) bool {
 	return userGroups.HasAny(adminGroups...)
 }
 
-// Logic for validation is implemented in verify method
-func (v *genericValidator) verify(obj map[string]interface{}, rule config.Rule) (bool, error) {
-	fieldPathParts := strings.Split(rule.Field, ".")
-	switch rule.Type {
-	case ValueTypeString:
-		{
-			var checkValues []string
-			checkValue, ok := rule.Value.(string)
-			if !ok {
-				if rule.Op != OperatorIn && rule.Op != OperatorNotIn {
-					return false, fmt.Errorf(
-						"Value (of type %T) in rule is not of type: %s with Operator %s",
-						rule.Value, rule.Type, rule.Op)
-				} else {
-					checkValues, ok = rule.Value.([]string)
-					if !ok {
-						return false, fmt.Errorf(
-							"Value (of type %T) in rule is not of type: []%s with Operator %s",
-							rule.Value, rule.Type, rule.Op)
-					}
-				}
-			}
-			val, ok, err := unstructured.NestedString(obj, fieldPathParts...)
-			if !ok {
-				return false, fmt.Errorf(
-					"Field not found at %s into %+v", rule.Field, obj)
-			}
-			if err != nil {
-				return false, err
+// Logic for validation is implemented in verify method, the rule is verified against every
+// value the field path resolves to and the results are combined according to the quantifier
+func (v *genericValidator) verify(rc *ruleContext, rule config.Rule) (bool, error) {
+	compiled, err := getCompiled(rule)
+	if err != nil {
+		return false, err
+	}
+	if changeOperators.Has(rule.Op) {
+		return v.verifyChange(rc, rule, compiled)
+	}
+	// as the change operators, the rules about the old object do not apply when there is none
+	if rc.oldObject == nil && compiled.usesOldObject() {
+		return false, errNotApplicable
+	}
+	obj := rc.object
+	if compiled.old {
+		obj = rc.oldObject
+	}
+	if rule.Op != OperatorExists && rule.Op != OperatorNotExists {
+		if rule, compiled, err = v.resolveValue(rc, rule, compiled); err != nil {
+			if isMissingValue(err) && rule.OnMissing == OnMissingAllow {
+				return true, nil
 			}
-			switch rule.Op {
-			case OperatorIsNot:
-				return val != checkValue, nil
-			case OperatorIs:
-				return val == checkValue, nil
-			case OperatorIn:
-				return sets.NewString(checkValues...).Has(val), nil
-			case OperatorNotIn:
-				return !sets.NewString(checkValues...).Has(val), nil
+			return false, err
+		}
+	}
+	values := compiled.path.Resolve(obj)
+	if mapOperators.Has(rule.Op) {
+		return verifyMap(values, rule, compiled)
+	}
+	// for image components they are verified on each value
+	if rule.Type != ValueTypeImage || rule.Component == "" {
+		switch rule.Op {
+		case OperatorExists:
+			return len(values) > 0, nil
+		case OperatorNotExists:
+			return len(values) == 0, nil
+		}
+	}
+	if len(values) == 0 {
+		switch rule.OnMissing {
+		case OnMissingAllow:
+			return true, nil
+		case OnMissingDefault:
+			values = []interface{}{compiled.defaultValue}
+		default:
+			return false, &missingValueError{field: rule.Field}
+		}
+	}
+	// the length is of the field value as it is, lists are not flattened
+	_, isLength := lengthOperators[rule.Op]
+	if itemType, isSlice := sliceValueTypes[rule.Type]; isSlice && !isLength {
+		return verifySlice(flatten(values), rule, itemType)
+	}
+	// a list of addresses (i.e. spec.externalIPs) is verified on each of them
+	if (rule.Type == ValueTypeIP || rule.Type == ValueTypeCIDR) && !isLength {
+		values = flatten(values)
+	}
+	// values that are not valid for the type and allowed are not considered
+	matching, considered := 0, 0
+	for _, value := range values {
+		ok, err := v.verifyValue(value, rule, compiled)
+		if _, isInvalid := err.(*invalidValueError); isInvalid {
+			switch getInvalidPolicy(rule) {
+			case OnMissingAllow:
+				continue
+			case OnMissingDefault:
+				ok, err = v.verifyValue(compiled.defaultValue, rule, compiled)
 			}
 		}
+		if err != nil {
+			return false, err
+		}
+		considered++
+		if ok {
+			matching++
+		}
+	}
+	if considered == 0 {
+		return true, nil
+	}
+	switch rule.Quantifier {
+	case QuantifierAny:
+		return matching > 0, nil
+	case QuantifierNone:
+		return matching == 0, nil
+	}
+	return matching == considered, nil
+}
+
+// getInvalidPolicy returns the policy for field values not valid for the rule type,
+// if not set it is the missing field one
+func getInvalidPolicy(rule config.Rule) MissingPolicy {
+	if rule.OnInvalid != "" {
+		return rule.OnInvalid
+	}
+	return rule.OnMissing
+}
+
+// verifyValue verifies the rule against a single value of the field
+func (v *genericValidator) verifyValue(value interface{}, rule config.Rule, compiled *compiledRule) (bool, error) {
+	if _, isLength := lengthOperators[rule.Op]; isLength {
+		return verifyLength(value, rule, compiled.length)
+	}
+	switch rule.Type {
+	case ValueTypeString:
+		return verifyString(value, rule, compiled)
+	case ValueTypeImage:
+		return verifyImage(value, rule, compiled)
+	case ValueTypeIP, ValueTypeCIDR:
+		return verifyNetwork(value, rule, compiled)
+	case ValueTypeObject:
+		return verifyObject(value, rule)
 	case ValueTypeBool:
 		{
 			checkValue, ok := rule.Value.(bool)
@@ -132,13 +250,10 @@ func (v *genericValidator) verify(obj map[string]interface{}, rule config.Rule)
 					"Value (of type %T) in rule is not of type: %s",
 					rule.Value, rule.Type)
 			}
-			val, ok, err := unstructured.NestedBool(obj, fieldPathParts...)
+			val, ok := value.(bool)
 			if !ok {
-				return false, fmt.Errorf(
-					"Field not found at %s into %+v", rule.Field, obj)
-			}
-			if err != nil {
-				return false, err
+				return false, fmt.Errorf("%v accessor error: %v is of the type %T, expected bool",
+					rule.Field, value, value)
 			}
 			switch rule.Op {
 			case OperatorIsNot:
@@ -147,69 +262,69 @@ func (v *genericValidator) verify(obj map[string]interface{}, rule config.Rule)
 				return val == checkValue, nil
 			}
 		}
-	case ValueTypeInt, ValueTypeInt64:
-		{
-			checkIntValue, ok := rule.Value.(int)
-			if !ok {
-				return false, fmt.Errorf(
-					"Value (of type %T) in rule is not of type: %s",
-					rule.Value, rule.Type)
-			}
-			checkValue := int64(checkIntValue)
-			val, ok, err := unstructured.NestedInt64(obj, fieldPathParts...)
-			if !ok {
-				return false, fmt.Errorf(
-					"Field not found at %s into %+v", rule.Field, obj)
-			}
-			if err != nil {
-				return false, err
-			}
-			switch rule.Op {
-			case OperatorIsNot:
-				return val != checkValue, nil
-			case OperatorIs:
-				return val == checkValue, nil
-			case OperatorGreaterThan, OperatorMoreThan:
-				return val > checkValue, nil
-			case OperatorSmallerThan, OperatorLessThan:
-				return val < checkValue, nil
-			case OperatorEqualOrMoreThan, OperatorEqualOrGreaterThan:
-				return val >= checkValue, nil
-			case OperatorEqualOrLessThan, OperatorEqualOrSmallerThan:
-				return val <= checkValue, nil
-			}
+	case ValueTypeInt, ValueTypeInt64, ValueTypeFloat, ValueTypeFloat64:
+		return verifyNumber(value, rule)
+	case ValueTypeIntOrString:
+		return verifyIntOrString(value, rule, compiled.values)
+	default:
+		if compiled.constraint != nil {
+			return verifySemverRange(value, rule, compiled.constraint)
 		}
-	case ValueTypeFloat, ValueTypeFloat64:
-		{
-			checkValue, ok := rule.Value.(float64)
-			if !ok {
-				return false, fmt.Errorf(
-					"Value (of type %T) in rule is not of type: %s",
-					rule.Value, rule.Type)
-			}
-			val, ok, err := unstructured.NestedFloat64(obj, fieldPathParts...)
-			if !ok {
-				return false, fmt.Errorf(
-					"Field not found at %s into %+v", rule.Field, obj)
-			}
-			if err != nil {
-				return false, err
-			}
-			switch rule.Op {
-			case OperatorIsNot:
-				return val != checkValue, nil
-			case OperatorIs:
-				return val == checkValue, nil
-			case OperatorGreaterThan, OperatorMoreThan:
-				return val > checkValue, nil
-			case OperatorSmallerThan, OperatorLessThan:
-				return val < checkValue, nil
-			case OperatorEqualOrMoreThan, OperatorEqualOrGreaterThan:
-				return val >= checkValue, nil
-			case OperatorEqualOrLessThan, OperatorEqualOrSmallerThan:
-				return val <= checkValue, nil
-			}
+		if ot, found := orderedTypes[rule.Type]; found {
+			return verifyOrdered(value, rule, ot, compiled.values)
 		}
 	}
 	return false, fmt.Errorf("unknonw type in rule: %v", rule)
 }
+
+// verifyString verifies a string value, also pattern matching it
+func verifyString(value interface{}, rule config.Rule, compiled *compiledRule) (bool, error) {
+	var checkValues []string
+	checkValue, ok := rule.Value.(string)
+	if !ok {
+		if _, isPatternOp := patternOperators[rule.Op]; isPatternOp {
+			checkValues, ok = toStringSlice(rule.Value)
+		} else if rule.Op == OperatorIn || rule.Op == OperatorNotIn {
+			checkValues, ok = toStringSlice(rule.Value)
+		}
+		if !ok {
+			return false, fmt.Errorf(
+				"Value (of type %T) in rule is not of type: %s with Operator %s",
+				rule.Value, rule.Type, rule.Op)
+		}
+	}
+	val, ok := value.(string)
+	if !ok {
+		return false, fmt.Errorf("%v accessor error: %v is of the type %T, expected string",
+			rule.Field, value, value)
+	}
+	if rule.IgnoreCase {
+		val, checkValue = strings.ToLower(val), strings.ToLower(checkValue)
+		lowerValues := make([]string, 0, len(checkValues))
+		for _, value := range checkValues {
+			lowerValues = append(lowerValues, strings.ToLower(value))
+		}
+		checkValues = lowerValues
+	}
+	switch rule.Op {
+	case OperatorIsNot:
+		return val != checkValue, nil
+	case OperatorIs:
+		return val == checkValue, nil
+	case OperatorIn:
+		return sets.NewString(checkValues...).Has(val), nil
+	case OperatorNotIn:
+		return !sets.NewString(checkValues...).Has(val), nil
+	}
+	if patternOp, found := patternOperators[rule.Op]; found {
+		matched := false
+		for _, re := range compiled.patterns {
+			if re.MatchString(val) {
+				matched = true
+				break
+			}
+		}
+		return matched != patternOp.negated, nil
+	}
+	return false, fmt.Errorf("unknown operator %s for type %s", rule.Op, rule.Type)
+}
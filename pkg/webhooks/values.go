@@ -0,0 +1,131 @@
+package webhooks
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/safanaj/k8s-generic-validator/pkg/config"
+)
+
+// sliceValueTypes maps the slice value types to the type of their items
+var sliceValueTypes = map[ValueType]ValueType{
+	ValueTypeStringSlice:  ValueTypeString,
+	ValueTypeBoolSlice:    ValueTypeBool,
+	ValueTypeIntSlice:     ValueTypeInt,
+	ValueTypeInt64Slice:   ValueTypeInt,
+	ValueTypeFloatSlice:   ValueTypeFloat,
+	ValueTypeFloat64Slice: ValueTypeFloat,
+}
+
+// toScalar converts a value (from the rule or from the object) to the canonical go type
+// for the value type: string, bool, int64 or float64, numbers are coerced (i.e. from numeric strings)
+func toScalar(value interface{}, valueType ValueType) (interface{}, bool) {
+	switch valueType {
+	case ValueTypeString:
+		v, ok := value.(string)
+		return v, ok
+	case ValueTypeBool:
+		v, ok := value.(bool)
+		return v, ok
+	case ValueTypeInt, ValueTypeInt64:
+		return toInt64(value)
+	case ValueTypeFloat, ValueTypeFloat64:
+		return toFloat64(value)
+	}
+	return nil, false
+}
+
+// toScalarSet converts a list (yaml or json decoded) to a set of values of the value type
+func toScalarSet(value interface{}, valueType ValueType) (map[interface{}]bool, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		if strings, isStrings := value.([]string); isStrings {
+			for _, s := range strings {
+				items = append(items, s)
+			}
+		} else {
+			return nil, fmt.Errorf("Value (of type %T) in rule is not a list of %s", value, valueType)
+		}
+	}
+	set := make(map[interface{}]bool, len(items))
+	for _, item := range items {
+		scalar, ok := toScalar(item, valueType)
+		if !ok {
+			return nil, fmt.Errorf("%v (of type %T) is not of type: %s", item, item, valueType)
+		}
+		set[scalar] = true
+	}
+	return set, nil
+}
+
+// flatten returns the values a field path resolved to, with the lists replaced by their items
+func flatten(values []interface{}) []interface{} {
+	flat := []interface{}{}
+	for _, value := range values {
+		if items, ok := value.([]interface{}); ok {
+			flat = append(flat, items...)
+		} else {
+			flat = append(flat, value)
+		}
+	}
+	return flat
+}
+
+// verifyIn verifies In and NotIn for numeric types
+func verifyIn(value interface{}, rule config.Rule) (bool, error) {
+	checkValues, err := toScalarSet(rule.Value, rule.Type)
+	if err != nil {
+		return false, err
+	}
+	val, ok := toScalar(value, rule.Type)
+	if !ok {
+		return false, fmt.Errorf("%v accessor error: %v is of the type %T, expected %s",
+			rule.Field, value, value, rule.Type)
+	}
+	return checkValues[val] == (rule.Op == OperatorIn), nil
+}
+
+// verifySlice verifies a rule of a slice value type, values are all the items of the field
+func verifySlice(values []interface{}, rule config.Rule, itemType ValueType) (bool, error) {
+	checkValues, err := toScalarSet(rule.Value, itemType)
+	if err != nil {
+		return false, err
+	}
+	fieldValues, err := toScalarSet(values, itemType)
+	if err != nil {
+		return false, fmt.Errorf("%v: %v", rule.Field, err)
+	}
+	common := 0
+	for value := range fieldValues {
+		if checkValues[value] {
+			common++
+		}
+	}
+	switch rule.Op {
+	case OperatorIs:
+		return common == len(fieldValues) && common == len(checkValues), nil
+	case OperatorIsNot:
+		return common != len(fieldValues) || common != len(checkValues), nil
+	case OperatorContainsAll:
+		return common == len(checkValues), nil
+	case OperatorContainsAny:
+		return common > 0, nil
+	case OperatorSubsetOf:
+		return common == len(fieldValues), nil
+	case OperatorDisjoint:
+		return common == 0, nil
+	}
+	return false, fmt.Errorf("unknown operator %s for type %s", rule.Op, rule.Type)
+}
+
+// verifyObject compares a value of any type, i.e. a map, with the rule one
+func verifyObject(value interface{}, rule config.Rule) (bool, error) {
+	equal := reflect.DeepEqual(value, normalizeYamlValue(rule.Value))
+	switch rule.Op {
+	case OperatorIs:
+		return equal, nil
+	case OperatorIsNot:
+		return !equal, nil
+	}
+	return false, fmt.Errorf("unknown operator %s for type %s", rule.Op, rule.Type)
+}